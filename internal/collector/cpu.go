@@ -0,0 +1,252 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterSource("cpu", newCPUSource)
+}
+
+// cpuSource собирает метрики процессора.
+// Утилизация вычисляется по разнице счетчиков времени CPU между циклами сбора,
+// поэтому значение усредняется за весь интервал и не требует ожидания.
+type cpuSource struct {
+	logger  *zap.Logger
+	perCore bool
+
+	mu          sync.Mutex
+	prev        *cpu.TimesStat
+	prevPerCore map[string]cpu.TimesStat
+}
+
+func newCPUSource(cfg Config, logger *zap.Logger) (Source, error) {
+	s := &cpuSource{
+		logger:  logger,
+		perCore: cfg.CPUPerCore,
+	}
+
+	// Берем начальные значения счетчиков, чтобы утилизация была доступна уже в первом цикле
+	if times, err := cpu.Times(false); err == nil && len(times) > 0 {
+		s.prev = &times[0]
+	}
+	if s.perCore {
+		if times, err := cpu.Times(true); err == nil {
+			s.prevPerCore = indexCPUTimes(times)
+		}
+	}
+
+	return s, nil
+}
+
+// Name возвращает имя источника
+func (s *cpuSource) Name() string {
+	return "cpu"
+}
+
+// Keys возвращает ключи Zabbix, публикуемые источником.
+// Ключи отдельных ядер создаются правилом обнаружения.
+func (s *cpuSource) Keys() []string {
+	keys := []string{
+		"system.cpu.util[,user]",
+		"system.cpu.util[,system]",
+		"system.cpu.util[,iowait]",
+		"system.cpu.util[,steal]",
+		"system.cpu.util[,interrupt]",
+		"system.cpu.util[,softirq]",
+		"system.cpu.util[,nice]",
+		"system.cpu.util[,guest]",
+		"system.cpu.util[,idle]",
+		"system.cpu.load[all,avg1]",
+		"system.cpu.load[all,avg5]",
+		"system.cpu.load[all,avg15]",
+		"system.cpu.load[percpu,avg1]",
+		"system.cpu.load[percpu,avg5]",
+		"system.cpu.load[percpu,avg15]",
+		"system.cpu.num",
+	}
+	if s.perCore {
+		keys = append(keys, "system.cpu.discovery")
+	}
+	return keys
+}
+
+// Collect собирает метрики процессора
+func (s *cpuSource) Collect(ctx context.Context, set *MetricSet) error {
+	// CPU Usage
+	times, err := cpu.TimesWithContext(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to get CPU times: %w", err)
+	}
+	if len(times) == 0 {
+		return fmt.Errorf("no CPU times reported")
+	}
+
+	s.mu.Lock()
+	prev := s.prev
+	s.prev = &times[0]
+	s.mu.Unlock()
+
+	// Load Average
+	loadAvg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to get load average", zap.Error(err))
+		// Load average не критично, продолжаем без него
+	}
+
+	var metrics CPUMetrics
+	if prev != nil {
+		metrics = cpuBreakdown(*prev, times[0])
+		addCPUUtil(set, "", metrics)
+	}
+
+	numCPU, err := cpu.CountsWithContext(ctx, true)
+	if err != nil {
+		s.logger.Warn("Failed to get CPU count", zap.Error(err))
+	}
+	metrics.NumCPU = numCPU
+
+	if loadAvg != nil {
+		metrics.LoadAvg1 = loadAvg.Load1
+		metrics.LoadAvg5 = loadAvg.Load5
+		metrics.LoadAvg15 = loadAvg.Load15
+
+		set.Add("system.cpu.load[all,avg1]", metrics.LoadAvg1)
+		set.Add("system.cpu.load[all,avg5]", metrics.LoadAvg5)
+		set.Add("system.cpu.load[all,avg15]", metrics.LoadAvg15)
+
+		// Нормализуем load average на количество логических процессоров
+		if numCPU > 0 {
+			metrics.LoadAvgPerCPU1 = metrics.LoadAvg1 / float64(numCPU)
+			metrics.LoadAvgPerCPU5 = metrics.LoadAvg5 / float64(numCPU)
+			metrics.LoadAvgPerCPU15 = metrics.LoadAvg15 / float64(numCPU)
+
+			set.Add("system.cpu.load[percpu,avg1]", metrics.LoadAvgPerCPU1)
+			set.Add("system.cpu.load[percpu,avg5]", metrics.LoadAvgPerCPU5)
+			set.Add("system.cpu.load[percpu,avg15]", metrics.LoadAvgPerCPU15)
+		}
+	}
+
+	if numCPU > 0 {
+		set.Add("system.cpu.num", numCPU)
+	}
+
+	set.CPU = metrics
+
+	if s.perCore {
+		if err := s.collectPerCore(ctx, set); err != nil {
+			s.logger.Warn("Failed to collect per-core CPU metrics", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// collectPerCore собирает утилизацию каждого ядра и данные обнаружения
+func (s *cpuSource) collectPerCore(ctx context.Context, set *MetricSet) error {
+	times, err := cpu.TimesWithContext(ctx, true)
+	if err != nil {
+		return fmt.Errorf("failed to get per-core CPU times: %w", err)
+	}
+
+	s.mu.Lock()
+	prev := s.prevPerCore
+	s.prevPerCore = indexCPUTimes(times)
+	s.mu.Unlock()
+
+	discovery := []map[string]string{}
+	for _, current := range times {
+		number, err := strconv.Atoi(strings.TrimPrefix(current.CPU, "cpu"))
+		if err != nil {
+			continue
+		}
+		discovery = append(discovery, map[string]string{
+			"{#CPU.NUMBER}": strconv.Itoa(number),
+			"{#CPU.STATUS}": "online",
+		})
+
+		previous, ok := prev[current.CPU]
+		if !ok {
+			continue
+		}
+
+		core := CoreMetrics{Number: number, CPUMetrics: cpuBreakdown(previous, current)}
+		set.CPUCores = append(set.CPUCores, core)
+
+		addCPUUtil(set, strconv.Itoa(number), core.CPUMetrics)
+	}
+
+	data, err := json.Marshal(discovery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal CPU discovery: %w", err)
+	}
+	set.Add("system.cpu.discovery", string(data))
+
+	return nil
+}
+
+// indexCPUTimes индексирует счетчики времени по имени процессора
+func indexCPUTimes(times []cpu.TimesStat) map[string]cpu.TimesStat {
+	index := make(map[string]cpu.TimesStat, len(times))
+	for _, t := range times {
+		index[t.CPU] = t
+	}
+	return index
+}
+
+// cpuTotal возвращает суммарное время CPU.
+// Время гостевых систем в Linux уже учтено в user и nice, поэтому не суммируется.
+func cpuTotal(t cpu.TimesStat) float64 {
+	return t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+}
+
+// cpuBreakdown вычисляет распределение времени CPU между двумя замерами счетчиков
+func cpuBreakdown(prev, current cpu.TimesStat) CPUMetrics {
+	total := cpuTotal(current) - cpuTotal(prev)
+	if total <= 0 {
+		return CPUMetrics{IdlePercent: 100}
+	}
+
+	percent := func(prev, current float64) float64 {
+		return math.Min(100, math.Max(0, (current-prev)/total*100))
+	}
+
+	metrics := CPUMetrics{
+		UserPercent:    percent(prev.User, current.User),
+		SystemPercent:  percent(prev.System, current.System),
+		IowaitPercent:  percent(prev.Iowait, current.Iowait),
+		StealPercent:   percent(prev.Steal, current.Steal),
+		IrqPercent:     percent(prev.Irq, current.Irq),
+		SoftirqPercent: percent(prev.Softirq, current.Softirq),
+		NicePercent:    percent(prev.Nice, current.Nice),
+		GuestPercent:   percent(prev.Guest, current.Guest),
+		IdlePercent:    percent(prev.Idle, current.Idle),
+	}
+	metrics.UsagePercent = math.Max(0, 100-metrics.IdlePercent-metrics.IowaitPercent)
+
+	return metrics
+}
+
+// addCPUUtil публикует распределение времени CPU под стандартными ключами агента.
+// Пустой cpuNum соответствует всем процессорам.
+func addCPUUtil(set *MetricSet, cpuNum string, metrics CPUMetrics) {
+	set.Add(fmt.Sprintf("system.cpu.util[%s,user]", cpuNum), metrics.UserPercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,system]", cpuNum), metrics.SystemPercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,iowait]", cpuNum), metrics.IowaitPercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,steal]", cpuNum), metrics.StealPercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,interrupt]", cpuNum), metrics.IrqPercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,softirq]", cpuNum), metrics.SoftirqPercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,nice]", cpuNum), metrics.NicePercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,guest]", cpuNum), metrics.GuestPercent)
+	set.Add(fmt.Sprintf("system.cpu.util[%s,idle]", cpuNum), metrics.IdlePercent)
+}
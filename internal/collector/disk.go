@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterSource("disk", newDiskSource)
+}
+
+// diskSource собирает метрики всех смонтированных файловых систем
+type diskSource struct {
+	logger       *zap.Logger
+	types        map[string]bool
+	excludeTypes map[string]bool
+	mountInclude *regexp.Regexp
+	mountExclude *regexp.Regexp
+}
+
+func newDiskSource(cfg Config, logger *zap.Logger) (Source, error) {
+	s := &diskSource{
+		logger:       logger,
+		types:        toSet(cfg.FSTypes),
+		excludeTypes: toSet(cfg.FSExcludeTypes),
+	}
+
+	var err error
+	if s.mountInclude, err = compilePattern(cfg.FSMountInclude); err != nil {
+		return nil, fmt.Errorf("invalid mountpoint include pattern: %w", err)
+	}
+	if s.mountExclude, err = compilePattern(cfg.FSMountExclude); err != nil {
+		return nil, fmt.Errorf("invalid mountpoint exclude pattern: %w", err)
+	}
+
+	return s, nil
+}
+
+// Name возвращает имя источника
+func (s *diskSource) Name() string {
+	return "disk"
+}
+
+// Keys возвращает ключи Zabbix, публикуемые источником.
+// Ключи отдельных файловых систем создаются правилом обнаружения.
+func (s *diskSource) Keys() []string {
+	return []string{"vfs.fs.discovery"}
+}
+
+// Collect собирает метрики всех подходящих файловых систем
+func (s *diskSource) Collect(ctx context.Context, set *MetricSet) error {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to get partitions: %w", err)
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].Mountpoint < partitions[j].Mountpoint
+	})
+
+	discovery := []map[string]string{}
+	seen := make(map[string]bool, len(partitions))
+	for _, partition := range partitions {
+		if seen[partition.Mountpoint] || !s.match(partition) {
+			continue
+		}
+		seen[partition.Mountpoint] = true
+
+		usage, err := disk.UsageWithContext(ctx, partition.Mountpoint)
+		if err != nil {
+			// Отдельные точки монтирования могут быть недоступны, пропускаем их
+			s.logger.Debug("Failed to get filesystem usage",
+				zap.String("mountpoint", partition.Mountpoint),
+				zap.Error(err))
+			continue
+		}
+
+		fs := FilesystemMetrics{
+			Mountpoint: partition.Mountpoint,
+			Device:     partition.Device,
+			FSType:     partition.Fstype,
+			DiskMetrics: DiskMetrics{
+				TotalBytes:        usage.Total,
+				UsedBytes:         usage.Used,
+				FreeBytes:         usage.Free,
+				UsagePercent:      usage.UsedPercent,
+				InodesTotal:       usage.InodesTotal,
+				InodesUsed:        usage.InodesUsed,
+				InodesFree:        usage.InodesFree,
+				InodesUsedPercent: usage.InodesUsedPercent,
+			},
+		}
+
+		set.Filesystems = append(set.Filesystems, fs)
+		if fs.Mountpoint == "/" {
+			set.Disk = fs.DiskMetrics
+		}
+
+		discovery = append(discovery, map[string]string{
+			"{#FSNAME}":   fs.Mountpoint,
+			"{#FSTYPE}":   fs.FSType,
+			"{#FSDEVICE}": fs.Device,
+		})
+
+		name := KeyParam(fs.Mountpoint)
+		set.Add(fmt.Sprintf("vfs.fs.size[%s,total]", name), fs.TotalBytes)
+		set.Add(fmt.Sprintf("vfs.fs.size[%s,used]", name), fs.UsedBytes)
+		set.Add(fmt.Sprintf("vfs.fs.size[%s,free]", name), fs.FreeBytes)
+		set.Add(fmt.Sprintf("vfs.fs.pused[%s]", name), fs.UsagePercent)
+		set.Add(fmt.Sprintf("vfs.fs.inode[%s,total]", name), fs.InodesTotal)
+		set.Add(fmt.Sprintf("vfs.fs.inode[%s,used]", name), fs.InodesUsed)
+		set.Add(fmt.Sprintf("vfs.fs.inode[%s,free]", name), fs.InodesFree)
+		set.Add(fmt.Sprintf("vfs.fs.inode[%s,pused]", name), fs.InodesUsedPercent)
+	}
+
+	data, err := json.Marshal(discovery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filesystem discovery: %w", err)
+	}
+	set.Add("vfs.fs.discovery", string(data))
+
+	return nil
+}
+
+// match проверяет, проходит ли файловая система фильтры по типу и точке монтирования
+func (s *diskSource) match(partition disk.PartitionStat) bool {
+	if len(s.types) > 0 && !s.types[partition.Fstype] {
+		return false
+	}
+	if s.excludeTypes[partition.Fstype] {
+		return false
+	}
+	if s.mountInclude != nil && !s.mountInclude.MatchString(partition.Mountpoint) {
+		return false
+	}
+	if s.mountExclude != nil && s.mountExclude.MatchString(partition.Mountpoint) {
+		return false
+	}
+	return true
+}
+
+// toSet преобразует список строк в множество
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// compilePattern компилирует регулярное выражение, пустой шаблон означает отсутствие фильтра
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
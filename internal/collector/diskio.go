@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterSource("diskio", newDiskIOSource)
+}
+
+// diskIOSource собирает статистику ввода-вывода блочных устройств
+type diskIOSource struct {
+	logger  *zap.Logger
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+
+	mu       sync.Mutex
+	prev     map[string]disk.IOCountersStat
+	prevTime time.Time
+}
+
+func newDiskIOSource(cfg Config, logger *zap.Logger) (Source, error) {
+	s := &diskIOSource{logger: logger}
+
+	var err error
+	if s.include, err = compilePattern(cfg.DevInclude); err != nil {
+		return nil, fmt.Errorf("invalid device include pattern: %w", err)
+	}
+	if s.exclude, err = compilePattern(cfg.DevExclude); err != nil {
+		return nil, fmt.Errorf("invalid device exclude pattern: %w", err)
+	}
+
+	return s, nil
+}
+
+// Name возвращает имя источника
+func (s *diskIOSource) Name() string {
+	return "diskio"
+}
+
+// Keys возвращает ключи Zabbix, публикуемые источником.
+// Ключи отдельных устройств создаются правилом обнаружения.
+func (s *diskIOSource) Keys() []string {
+	return []string{"vfs.dev.discovery"}
+}
+
+// Collect собирает статистику ввода-вывода блочных устройств
+func (s *diskIOSource) Collect(ctx context.Context, set *MetricSet) error {
+	counters, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get disk I/O statistics: %w", err)
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	prev, prevTime := s.prev, s.prevTime
+	s.prev, s.prevTime = counters, now
+	s.mu.Unlock()
+	elapsedMs := float64(now.Sub(prevTime).Milliseconds())
+
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		if s.match(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	discovery := []map[string]string{}
+	for _, name := range names {
+		stat := counters[name]
+		device := BlockDeviceMetrics{
+			Name:        name,
+			Type:        deviceType(name),
+			ReadOps:     stat.ReadCount,
+			WriteOps:    stat.WriteCount,
+			ReadMerged:  stat.MergedReadCount,
+			WriteMerged: stat.MergedWriteCount,
+			ReadBytes:   stat.ReadBytes,
+			WriteBytes:  stat.WriteBytes,
+			ReadTimeMs:  stat.ReadTime,
+			WriteTimeMs: stat.WriteTime,
+			IOTimeMs:    stat.IoTime,
+			InProgress:  stat.IopsInProgress,
+		}
+
+		discovery = append(discovery, map[string]string{
+			"{#DEVNAME}": device.Name,
+			"{#DEVTYPE}": device.Type,
+		})
+
+		dev := KeyParam(name)
+		set.AddCounter(fmt.Sprintf("vfs.dev.read[%s,operations]", dev), fmt.Sprintf("vfs.dev.read[%s,ops]", dev), device.ReadOps)
+		set.AddCounter(fmt.Sprintf("vfs.dev.write[%s,operations]", dev), fmt.Sprintf("vfs.dev.write[%s,ops]", dev), device.WriteOps)
+		set.AddCounter(fmt.Sprintf("vfs.dev.read[%s,bytes]", dev), fmt.Sprintf("vfs.dev.read[%s,bps]", dev), device.ReadBytes)
+		set.AddCounter(fmt.Sprintf("vfs.dev.write[%s,bytes]", dev), fmt.Sprintf("vfs.dev.write[%s,bps]", dev), device.WriteBytes)
+		addCounter(set, fmt.Sprintf("vfs.dev.read[%s,merged]", dev), device.ReadMerged)
+		addCounter(set, fmt.Sprintf("vfs.dev.write[%s,merged]", dev), device.WriteMerged)
+		set.Add(fmt.Sprintf("vfs.dev.read[%s,time]", dev), device.ReadTimeMs)
+		set.Add(fmt.Sprintf("vfs.dev.write[%s,time]", dev), device.WriteTimeMs)
+		set.Add(fmt.Sprintf("vfs.dev.in_progress[%s]", dev), device.InProgress)
+
+		// Задержки, загрузка и длина очереди вычисляются по разнице с предыдущим замером
+		previous, ok := prev[name]
+		if ok && elapsedMs > 0 && !counterDecreased(previous, stat) {
+			device.ReadAwaitMs = await(previous.ReadTime, stat.ReadTime, previous.ReadCount, stat.ReadCount)
+			device.WriteAwaitMs = await(previous.WriteTime, stat.WriteTime, previous.WriteCount, stat.WriteCount)
+			device.UtilPercent = min(100, float64(stat.IoTime-previous.IoTime)/elapsedMs*100)
+			device.QueueSize = float64(stat.WeightedIO-previous.WeightedIO) / elapsedMs
+
+			set.Add(fmt.Sprintf("vfs.dev.read.await[%s]", dev), device.ReadAwaitMs)
+			set.Add(fmt.Sprintf("vfs.dev.write.await[%s]", dev), device.WriteAwaitMs)
+			set.Add(fmt.Sprintf("vfs.dev.util[%s]", dev), device.UtilPercent)
+			set.Add(fmt.Sprintf("vfs.dev.queue_size[%s]", dev), device.QueueSize)
+		}
+
+		set.BlockDevices = append(set.BlockDevices, device)
+	}
+
+	data, err := json.Marshal(discovery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal block device discovery: %w", err)
+	}
+	set.Add("vfs.dev.discovery", string(data))
+
+	return nil
+}
+
+// match проверяет, проходит ли устройство фильтры по имени
+func (s *diskIOSource) match(name string) bool {
+	if s.include != nil && !s.include.MatchString(name) {
+		return false
+	}
+	if s.exclude != nil && s.exclude.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// deviceType определяет тип блочного устройства: диск или раздел
+func deviceType(name string) string {
+	if _, err := os.Stat(filepath.Join("/sys/block", name)); err == nil {
+		return "disk"
+	}
+	return "partition"
+}
+
+// counterDecreased проверяет, были ли счетчики устройства сброшены
+func counterDecreased(prev, current disk.IOCountersStat) bool {
+	return current.ReadCount < prev.ReadCount || current.WriteCount < prev.WriteCount ||
+		current.ReadTime < prev.ReadTime || current.WriteTime < prev.WriteTime ||
+		current.IoTime < prev.IoTime || current.WeightedIO < prev.WeightedIO
+}
+
+// await вычисляет среднее время выполнения операции в миллисекундах
+func await(prevMs, ms, prevCount, count uint64) float64 {
+	if count <= prevCount {
+		return 0
+	}
+	return float64(ms-prevMs) / float64(count-prevCount)
+}
@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v3/mem"
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterSource("memory", newMemorySource)
+}
+
+// swapPageSize размер страницы, в которых ядро считает pswpin/pswpout
+const swapPageSize = 4096
+
+// memorySource собирает метрики памяти и файла подкачки
+type memorySource struct {
+	logger *zap.Logger
+}
+
+func newMemorySource(cfg Config, logger *zap.Logger) (Source, error) {
+	return &memorySource{logger: logger}, nil
+}
+
+// Name возвращает имя источника
+func (s *memorySource) Name() string {
+	return "memory"
+}
+
+// Keys возвращает ключи Zabbix, публикуемые источником
+func (s *memorySource) Keys() []string {
+	return []string{
+		"vm.memory.size[total]",
+		"vm.memory.size[used]",
+		"vm.memory.size[free]",
+		"vm.memory.size[available]",
+		"vm.memory.util",
+		"vm.memory.size[buffers]",
+		"vm.memory.size[cached]",
+		"vm.memory.size[shared]",
+		"vm.memory.size[slab]",
+		"vm.memory.size[dirty]",
+		"vm.memory.size[writeback]",
+		"vm.memory.size[committed_as]",
+		"vm.memory.size[hugepages_total]",
+		"vm.memory.size[hugepages_free]",
+		"system.swap.size[,total]",
+		"system.swap.size[,used]",
+		"system.swap.size[,free]",
+		"system.swap.size[,pused]",
+		"system.swap.size[,pfree]",
+		"system.swap.in[,pages]",
+		"system.swap.out[,pages]",
+		"system.swap.in.rate[,pages]",
+		"system.swap.out.rate[,pages]",
+	}
+}
+
+// Collect собирает метрики памяти
+func (s *memorySource) Collect(ctx context.Context, set *MetricSet) error {
+	vmStat, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get memory statistics: %w", err)
+	}
+
+	metrics := MemoryMetrics{
+		TotalBytes:          vmStat.Total,
+		UsedBytes:           vmStat.Used,
+		FreeBytes:           vmStat.Free,
+		UsagePercent:        vmStat.UsedPercent,
+		AvailableBytes:      vmStat.Available,
+		BuffersBytes:        vmStat.Buffers,
+		CachedBytes:         vmStat.Cached,
+		SharedBytes:         vmStat.Shared,
+		SlabBytes:           vmStat.Slab,
+		DirtyBytes:          vmStat.Dirty,
+		WritebackBytes:      vmStat.WriteBack,
+		CommittedASBytes:    vmStat.CommittedAS,
+		HugePagesTotalBytes: vmStat.HugePagesTotal * vmStat.HugePageSize,
+		HugePagesFreeBytes:  vmStat.HugePagesFree * vmStat.HugePageSize,
+	}
+
+	set.Add("vm.memory.size[total]", metrics.TotalBytes)
+	set.Add("vm.memory.size[used]", metrics.UsedBytes)
+	set.Add("vm.memory.size[free]", metrics.FreeBytes)
+	set.Add("vm.memory.size[available]", metrics.AvailableBytes)
+	set.Add("vm.memory.util", metrics.UsagePercent)
+	set.Add("vm.memory.size[buffers]", metrics.BuffersBytes)
+	set.Add("vm.memory.size[cached]", metrics.CachedBytes)
+	set.Add("vm.memory.size[shared]", metrics.SharedBytes)
+	set.Add("vm.memory.size[slab]", metrics.SlabBytes)
+	set.Add("vm.memory.size[dirty]", metrics.DirtyBytes)
+	set.Add("vm.memory.size[writeback]", metrics.WritebackBytes)
+	set.Add("vm.memory.size[committed_as]", metrics.CommittedASBytes)
+	set.Add("vm.memory.size[hugepages_total]", metrics.HugePagesTotalBytes)
+	set.Add("vm.memory.size[hugepages_free]", metrics.HugePagesFreeBytes)
+
+	// Файл подкачки не критичен, продолжаем без него
+	swapStat, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to get swap statistics", zap.Error(err))
+		set.Memory = metrics
+		return nil
+	}
+
+	metrics.Swap = SwapMetrics{
+		TotalBytes:   swapStat.Total,
+		UsedBytes:    swapStat.Used,
+		FreeBytes:    swapStat.Free,
+		UsagePercent: swapStat.UsedPercent,
+		PagesIn:      swapStat.Sin / swapPageSize,
+		PagesOut:     swapStat.Sout / swapPageSize,
+	}
+	set.Memory = metrics
+
+	set.Add("system.swap.size[,total]", metrics.Swap.TotalBytes)
+	set.Add("system.swap.size[,used]", metrics.Swap.UsedBytes)
+	set.Add("system.swap.size[,free]", metrics.Swap.FreeBytes)
+	set.Add("system.swap.size[,pused]", metrics.Swap.UsagePercent)
+	if metrics.Swap.TotalBytes > 0 {
+		set.Add("system.swap.size[,pfree]", 100-metrics.Swap.UsagePercent)
+	}
+	addCounter(set, "system.swap.in[,pages]", metrics.Swap.PagesIn)
+	addCounter(set, "system.swap.out[,pages]", metrics.Swap.PagesOut)
+
+	return nil
+}
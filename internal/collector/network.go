@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"go.uber.org/zap"
+)
+
+func init() {
+	RegisterSource("network", newNetworkSource)
+}
+
+// networkSource собирает метрики сети по всем интерфейсам и по каждому интерфейсу отдельно
+type networkSource struct {
+	logger  *zap.Logger
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+func newNetworkSource(cfg Config, logger *zap.Logger) (Source, error) {
+	s := &networkSource{logger: logger}
+
+	var err error
+	if s.include, err = compilePattern(cfg.NetIfInclude); err != nil {
+		return nil, fmt.Errorf("invalid interface include pattern: %w", err)
+	}
+	if s.exclude, err = compilePattern(cfg.NetIfExclude); err != nil {
+		return nil, fmt.Errorf("invalid interface exclude pattern: %w", err)
+	}
+
+	return s, nil
+}
+
+// Name возвращает имя источника
+func (s *networkSource) Name() string {
+	return "network"
+}
+
+// Keys возвращает ключи Zabbix, публикуемые источником.
+// Ключи отдельных интерфейсов создаются правилом обнаружения.
+func (s *networkSource) Keys() []string {
+	return []string{
+		"net.if.in[all]",
+		"net.if.out[all]",
+		"net.if.in[all,packets]",
+		"net.if.out[all,packets]",
+		"net.if.in[all,errors]",
+		"net.if.out[all,errors]",
+		"net.if.in.rate[all]",
+		"net.if.out.rate[all]",
+		"net.if.in.rate[all,packets]",
+		"net.if.out.rate[all,packets]",
+		"net.if.in.rate[all,errors]",
+		"net.if.out.rate[all,errors]",
+		"net.if.discovery",
+	}
+}
+
+// Collect собирает метрики сети
+func (s *networkSource) Collect(ctx context.Context, set *MetricSet) error {
+	netStats, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return fmt.Errorf("failed to get network statistics: %w", err)
+	}
+
+	// Суммируем статистику по всем интерфейсам (для обратной совместимости)
+	metrics := NetworkMetrics{}
+	discovery := []map[string]string{}
+	for _, stat := range netStats {
+		ifMetrics := NetworkMetrics{
+			BytesSent:   stat.BytesSent,
+			BytesRecv:   stat.BytesRecv,
+			PacketsSent: stat.PacketsSent,
+			PacketsRecv: stat.PacketsRecv,
+			ErrorsIn:    stat.Errin,
+			ErrorsOut:   stat.Errout,
+			DropsIn:     stat.Dropin,
+			DropsOut:    stat.Dropout,
+		}
+
+		metrics.BytesSent += ifMetrics.BytesSent
+		metrics.BytesRecv += ifMetrics.BytesRecv
+		metrics.PacketsSent += ifMetrics.PacketsSent
+		metrics.PacketsRecv += ifMetrics.PacketsRecv
+		metrics.ErrorsIn += ifMetrics.ErrorsIn
+		metrics.ErrorsOut += ifMetrics.ErrorsOut
+		metrics.DropsIn += ifMetrics.DropsIn
+		metrics.DropsOut += ifMetrics.DropsOut
+
+		if !s.match(stat.Name) {
+			continue
+		}
+
+		set.Interfaces = append(set.Interfaces, InterfaceMetrics{
+			Name:           stat.Name,
+			NetworkMetrics: ifMetrics,
+		})
+		discovery = append(discovery, map[string]string{"{#IFNAME}": stat.Name})
+
+		name := KeyParam(stat.Name)
+		addCounter(set, fmt.Sprintf("net.if.in[%s]", name), ifMetrics.BytesRecv)
+		addCounter(set, fmt.Sprintf("net.if.out[%s]", name), ifMetrics.BytesSent)
+		addCounter(set, fmt.Sprintf("net.if.in[%s,packets]", name), ifMetrics.PacketsRecv)
+		addCounter(set, fmt.Sprintf("net.if.out[%s,packets]", name), ifMetrics.PacketsSent)
+		addCounter(set, fmt.Sprintf("net.if.in[%s,errors]", name), ifMetrics.ErrorsIn)
+		addCounter(set, fmt.Sprintf("net.if.out[%s,errors]", name), ifMetrics.ErrorsOut)
+		addCounter(set, fmt.Sprintf("net.if.in[%s,dropped]", name), ifMetrics.DropsIn)
+		addCounter(set, fmt.Sprintf("net.if.out[%s,dropped]", name), ifMetrics.DropsOut)
+	}
+
+	set.Network = metrics
+	addCounter(set, "net.if.in[all]", metrics.BytesRecv)
+	addCounter(set, "net.if.out[all]", metrics.BytesSent)
+	addCounter(set, "net.if.in[all,packets]", metrics.PacketsRecv)
+	addCounter(set, "net.if.out[all,packets]", metrics.PacketsSent)
+	addCounter(set, "net.if.in[all,errors]", metrics.ErrorsIn)
+	addCounter(set, "net.if.out[all,errors]", metrics.ErrorsOut)
+
+	data, err := json.Marshal(discovery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal interface discovery: %w", err)
+	}
+	set.Add("net.if.discovery", string(data))
+
+	return nil
+}
+
+// match проверяет, проходит ли интерфейс фильтры по имени
+func (s *networkSource) match(name string) bool {
+	if s.include != nil && !s.include.MatchString(name) {
+		return false
+	}
+	if s.exclude != nil && s.exclude.MatchString(name) {
+		return false
+	}
+	return true
+}
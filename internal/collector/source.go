@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Source описывает отдельный источник метрик (CPU, память, диск и т.д.)
+type Source interface {
+	// Name возвращает уникальное имя источника, по которому его можно включить или отключить
+	Name() string
+	// Keys возвращает ключи Zabbix, которые публикует источник
+	Keys() []string
+	// Collect собирает метрики и записывает их в набор
+	Collect(ctx context.Context, set *MetricSet) error
+}
+
+// SourceFactory создает источник метрик с учетом конфигурации сборщика
+type SourceFactory func(cfg Config, logger *zap.Logger) (Source, error)
+
+var (
+	sourcesMutex sync.RWMutex
+	sources      = make(map[string]SourceFactory)
+)
+
+// RegisterSource регистрирует фабрику источника метрик под указанным именем.
+// Обычно вызывается из init() файла с реализацией источника.
+func RegisterSource(name string, factory SourceFactory) {
+	sourcesMutex.Lock()
+	defer sourcesMutex.Unlock()
+
+	if factory == nil {
+		panic("collector: RegisterSource factory is nil")
+	}
+	if _, exists := sources[name]; exists {
+		panic(fmt.Sprintf("collector: RegisterSource called twice for source %q", name))
+	}
+	sources[name] = factory
+}
+
+// SourceNames возвращает отсортированный список зарегистрированных источников
+func SourceNames() []string {
+	sourcesMutex.RLock()
+	defer sourcesMutex.RUnlock()
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildSources создает включенные в конфигурации источники метрик
+func buildSources(cfg Config, logger *zap.Logger) ([]Source, error) {
+	sourcesMutex.RLock()
+	defer sourcesMutex.RUnlock()
+
+	for _, name := range append(append([]string{}, cfg.Sources...), cfg.DisabledSources...) {
+		if _, exists := sources[name]; !exists {
+			return nil, fmt.Errorf("unknown metric source: %s", name)
+		}
+	}
+
+	enabled := cfg.Sources
+	if len(enabled) == 0 {
+		for name := range sources {
+			enabled = append(enabled, name)
+		}
+	}
+
+	disabled := make(map[string]bool, len(cfg.DisabledSources))
+	for _, name := range cfg.DisabledSources {
+		disabled[name] = true
+	}
+
+	seen := make(map[string]bool, len(enabled))
+	var result []Source
+	for _, name := range enabled {
+		if disabled[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		source, err := sources[name](cfg, logger.With(zap.String("source", name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create metric source %s: %w", name, err)
+		}
+		result = append(result, source)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+
+	return result, nil
+}
+
+// KeyParam экранирует параметр ключа Zabbix, если он содержит спецсимволы
+func KeyParam(param string) string {
+	if !strings.ContainsAny(param, ",]\" ") && !strings.HasPrefix(param, "[") {
+		return param
+	}
+	return `"` + strings.ReplaceAll(param, `"`, `\"`) + `"`
+}
+
+// addCounter сохраняет 64-битный счетчик, скорость которого публикуется под ключом с суффиксом .rate
+func addCounter(set *MetricSet, key string, value uint64) {
+	set.AddCounter(key, RateKey(key), value)
+}
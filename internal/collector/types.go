@@ -1,6 +1,11 @@
 package collector
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
 // MetricSet содержит все собранные метрики системы
 type MetricSet struct {
@@ -9,6 +14,97 @@ type MetricSet struct {
 	Memory    MemoryMetrics  `json:"memory"`
 	Disk      DiskMetrics    `json:"disk"`
 	Network   NetworkMetrics `json:"network"`
+
+	// CPUCores содержит утилизацию отдельных ядер процессора
+	CPUCores []CoreMetrics `json:"cpu_cores"`
+	// Filesystems содержит метрики всех обнаруженных файловых систем
+	Filesystems []FilesystemMetrics `json:"filesystems"`
+	// BlockDevices содержит статистику ввода-вывода блочных устройств
+	BlockDevices []BlockDeviceMetrics `json:"block_devices"`
+	// Interfaces содержит метрики отдельных сетевых интерфейсов
+	Interfaces []InterfaceMetrics `json:"interfaces"`
+
+	// Values содержит значения метрик по ключам Zabbix
+	Values map[string]interface{} `json:"values"`
+	// Counters содержит накопительные счетчики, для которых вычисляются скорости
+	Counters map[string]Counter `json:"counters"`
+	// Sources содержит результаты работы каждого источника
+	Sources []SourceStatus `json:"sources"`
+
+	mu sync.Mutex
+}
+
+// NewMetricSet создает пустой набор метрик с указанным временем сбора
+func NewMetricSet(timestamp time.Time) *MetricSet {
+	return &MetricSet{
+		Timestamp: timestamp,
+		Values:    make(map[string]interface{}),
+		Counters:  make(map[string]Counter),
+	}
+}
+
+// Add сохраняет значение метрики под ключом Zabbix
+func (m *MetricSet) Add(key string, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Values == nil {
+		m.Values = make(map[string]interface{})
+	}
+	m.Values[key] = value
+}
+
+// AddCounter сохраняет значение 64-битного накопительного счетчика под ключом Zabbix.
+// Скорость изменения счетчика публикуется под ключом rateKey.
+func (m *MetricSet) AddCounter(key, rateKey string, value uint64) {
+	m.Add(key, value)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Counters == nil {
+		m.Counters = make(map[string]Counter)
+	}
+	m.Counters[key] = Counter{Value: value, RateKey: rateKey}
+}
+
+// Get возвращает значение метрики по ключу Zabbix
+func (m *MetricSet) Get(key string) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.Values[key]
+	return value, ok
+}
+
+// Keys возвращает отсортированный список ключей собранных метрик
+func (m *MetricSet) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.Values))
+	for key := range m.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter содержит значение накопительного счетчика
+type Counter struct {
+	Value   uint64 `json:"value"`
+	RateKey string `json:"rate_key"`
+}
+
+// RateKey возвращает ключ скорости для ключа счетчика: net.if.in[eth0] -> net.if.in.rate[eth0]
+func RateKey(key string) string {
+	if i := strings.Index(key, "["); i >= 0 {
+		return key[:i] + ".rate" + key[i:]
+	}
+	return key + ".rate"
+}
+
+// SourceStatus содержит результат работы источника метрик за один цикл сбора
+type SourceStatus struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
 }
 
 // CPUMetrics содержит метрики процессора
@@ -17,6 +113,29 @@ type CPUMetrics struct {
 	LoadAvg1     float64 `json:"load_avg_1"`
 	LoadAvg5     float64 `json:"load_avg_5"`
 	LoadAvg15    float64 `json:"load_avg_15"`
+
+	// Load average в расчете на один логический процессор
+	LoadAvgPerCPU1  float64 `json:"load_avg_per_cpu_1"`
+	LoadAvgPerCPU5  float64 `json:"load_avg_per_cpu_5"`
+	LoadAvgPerCPU15 float64 `json:"load_avg_per_cpu_15"`
+	NumCPU          int     `json:"num_cpu"`
+
+	// Распределение времени CPU за интервал сбора (%)
+	UserPercent    float64 `json:"user_percent"`
+	SystemPercent  float64 `json:"system_percent"`
+	IowaitPercent  float64 `json:"iowait_percent"`
+	StealPercent   float64 `json:"steal_percent"`
+	IrqPercent     float64 `json:"irq_percent"`
+	SoftirqPercent float64 `json:"softirq_percent"`
+	NicePercent    float64 `json:"nice_percent"`
+	GuestPercent   float64 `json:"guest_percent"`
+	IdlePercent    float64 `json:"idle_percent"`
+}
+
+// CoreMetrics содержит метрики отдельного ядра процессора
+type CoreMetrics struct {
+	Number int `json:"number"`
+	CPUMetrics
 }
 
 // MemoryMetrics содержит метрики памяти
@@ -26,6 +145,29 @@ type MemoryMetrics struct {
 	FreeBytes      uint64  `json:"free_bytes"`
 	UsagePercent   float64 `json:"usage_percent"`
 	AvailableBytes uint64  `json:"available_bytes"`
+
+	// Расширенные метрики Linux
+	BuffersBytes        uint64 `json:"buffers_bytes"`
+	CachedBytes         uint64 `json:"cached_bytes"`
+	SharedBytes         uint64 `json:"shared_bytes"`
+	SlabBytes           uint64 `json:"slab_bytes"`
+	DirtyBytes          uint64 `json:"dirty_bytes"`
+	WritebackBytes      uint64 `json:"writeback_bytes"`
+	CommittedASBytes    uint64 `json:"committed_as_bytes"`
+	HugePagesTotalBytes uint64 `json:"hugepages_total_bytes"`
+	HugePagesFreeBytes  uint64 `json:"hugepages_free_bytes"`
+
+	Swap SwapMetrics `json:"swap"`
+}
+
+// SwapMetrics содержит метрики файла подкачки
+type SwapMetrics struct {
+	TotalBytes   uint64  `json:"total_bytes"`
+	UsedBytes    uint64  `json:"used_bytes"`
+	FreeBytes    uint64  `json:"free_bytes"`
+	UsagePercent float64 `json:"usage_percent"`
+	PagesIn      uint64  `json:"pages_in"`
+	PagesOut     uint64  `json:"pages_out"`
 }
 
 // DiskMetrics содержит метрики диска
@@ -40,6 +182,14 @@ type DiskMetrics struct {
 	InodesUsedPercent float64 `json:"inodes_used_percent"`
 }
 
+// FilesystemMetrics содержит метрики отдельной файловой системы
+type FilesystemMetrics struct {
+	Mountpoint string `json:"mountpoint"`
+	Device     string `json:"device"`
+	FSType     string `json:"fstype"`
+	DiskMetrics
+}
+
 // NetworkMetrics содержит метрики сети
 type NetworkMetrics struct {
 	BytesSent   uint64 `json:"bytes_sent"`
@@ -51,3 +201,29 @@ type NetworkMetrics struct {
 	DropsIn     uint64 `json:"drops_in"`
 	DropsOut    uint64 `json:"drops_out"`
 }
+
+// InterfaceMetrics содержит метрики отдельного сетевого интерфейса
+type InterfaceMetrics struct {
+	Name string `json:"name"`
+	NetworkMetrics
+}
+
+// BlockDeviceMetrics содержит статистику ввода-вывода блочного устройства
+type BlockDeviceMetrics struct {
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	ReadOps      uint64  `json:"read_ops"`
+	WriteOps     uint64  `json:"write_ops"`
+	ReadMerged   uint64  `json:"read_merged"`
+	WriteMerged  uint64  `json:"write_merged"`
+	ReadBytes    uint64  `json:"read_bytes"`
+	WriteBytes   uint64  `json:"write_bytes"`
+	ReadTimeMs   uint64  `json:"read_time_ms"`
+	WriteTimeMs  uint64  `json:"write_time_ms"`
+	IOTimeMs     uint64  `json:"io_time_ms"`
+	InProgress   uint64  `json:"in_progress"`
+	ReadAwaitMs  float64 `json:"read_await_ms"`
+	WriteAwaitMs float64 `json:"write_await_ms"`
+	UtilPercent  float64 `json:"util_percent"`
+	QueueSize    float64 `json:"queue_size"`
+}
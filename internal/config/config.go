@@ -3,30 +3,137 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
+	"zabbix_mon/pkg/zabbix"
+
 	"github.com/spf13/cobra"
 )
 
+// Режимы работы
+const (
+	ModeTrapper = "trapper" // создание trapper элементов через API и отправка через sender data
+	ModeActive  = "active"  // протокол активного агента (active checks, agent data)
+	ModePassive = "passive" // только ответы на пассивные проверки, без отправки
+)
+
+// DefaultStateFile файл состояния счетчиков по умолчанию
+const DefaultStateFile = "/var/lib/zabbix_mon/state.json"
+
 // Config содержит всю конфигурацию приложения
 type Config struct {
 	// Zabbix настройки
 	ZabbixURL      string
 	ZabbixUser     string
 	ZabbixPassword string
+	ZabbixAPIToken string // API токен вместо имени пользователя и пароля (Zabbix 5.4+)
 	ZabbixHost     string
 
+	// Автоматическая регистрация хоста
+	AutoRegister    bool
+	HostGroups      []string
+	HostTemplates   []string
+	HostVisibleName string
+	HostTags        []string // теги вида tag=value
+	HostInterface   string   // адрес агентского интерфейса host[:port]
+
+	// Триггеры, создаваемые вместе с элементами данных
+	CreateTriggers bool
+	TriggersFile   string // JSON файл с дополнительными и измененными триггерами
+
+	// Приведение существующих элементов данных к каталогу
+	ReconcileItems  bool
+	ReconcilePrune  string // действие с устаревшими элементами (disable, delete)
+	ReconcileDryRun bool
+
+	// Удаление элементов прежних версий, мешающих обнаружению
+	MigrateLegacyItems bool
+
+	// Адреса trapper host:port (пусто - хост из ZabbixURL и порт 10051)
+	ZabbixServers []string
+
+	// Политика доставки при нескольких адресах и автоматический выключатель
+	SenderPolicy     string
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// Режим работы и параметры активного агента
+	Mode          string
+	HostMetadata  string
+	ActiveRefresh time.Duration
+
+	// Пассивные проверки (пустой адрес - отключены)
+	PassiveListen  string
+	AllowedServers []string
+	PassiveTimeout time.Duration
+
 	// Общие настройки
 	Interval  time.Duration
 	LogLevel  string
 	BatchSize int
 
+	// Файл состояния для вычисления скоростей счетчиков между перезапусками
+	// (пустой путь - состояние не сохраняется)
+	StateFile string
+
+	// Очередь неотправленных значений на диске (пустой каталог - очередь отключена)
+	QueueDir     string
+	QueueMaxSize int64
+	QueueMaxAge  time.Duration
+
+	// Источники метрик
+	Sources         []string
+	DisabledSources []string
+
+	// Утилизация каждого ядра процессора
+	CPUPerCore bool
+
+	// Фильтры файловых систем
+	FSTypes        []string
+	FSExcludeTypes []string
+	FSMountInclude string
+	FSMountExclude string
+
+	// Фильтры сетевых интерфейсов
+	NetIfInclude string
+	NetIfExclude string
+
+	// Фильтры блочных устройств
+	DevInclude string
+	DevExclude string
+
 	// HTTP клиент настройки
 	HTTPTimeout      time.Duration
 	MaxRetries       int
 	RetryBackoffBase time.Duration
 
+	// Ограничения пакетов Sender
+	MaxPacketSize     int
+	SenderParallelism int
+
+	// Сжатие пакетов Sender (zlib)
+	SenderCompress bool
+
+	// Таймауты и пул соединений Sender
+	SenderDialTimeout  time.Duration
+	SenderReadTimeout  time.Duration
+	SenderWriteTimeout time.Duration
+	SenderPoolSize     int
+
+	// Шифрование соединения с сервером (аналог параметров TLS* zabbix_agentd)
+	TLSConnect           string
+	TLSCAFile            string
+	TLSCertFile          string
+	TLSKeyFile           string
+	TLSServerCertIssuer  string
+	TLSServerCertSubject string
+
+	// Поиск отклоненных значений делением пакета (значения могут дублироваться)
+	SenderBisect bool
+
 	// Профилирование
 	ProfileEnable   bool
 	ProfileHTTPPort int
@@ -38,21 +145,44 @@ type Config struct {
 // NewConfig создает новую конфигурацию с значениями по умолчанию
 func NewConfig() *Config {
 	return &Config{
-		ZabbixURL:        "http://localhost:10051/api_jsonrpc.php",
-		ZabbixUser:       "Admin",
-		ZabbixPassword:   "zabbix",
-		ZabbixHost:       "monitoring-host",
-		Interval:         10 * time.Second,
-		LogLevel:         "info",
-		BatchSize:        50,
-		HTTPTimeout:      30 * time.Second,
-		MaxRetries:       3,
-		RetryBackoffBase: 1 * time.Second,
-		ProfileEnable:    false,
-		ProfileHTTPPort:  6060,
-		ProfileCPUFile:   "",
-		ProfileMemFile:   "",
-		ProfileTime:      30,
+		ZabbixURL:          "http://localhost:10051/api_jsonrpc.php",
+		ZabbixUser:         "Admin",
+		ZabbixPassword:     "zabbix",
+		ZabbixHost:         "monitoring-host",
+		Mode:               ModeTrapper,
+		CreateTriggers:     true,
+		ReconcilePrune:     zabbix.PruneDisable,
+		ActiveRefresh:      120 * time.Second,
+		AllowedServers:     []string{"127.0.0.1", "::1"},
+		PassiveTimeout:     3 * time.Second,
+		Interval:           10 * time.Second,
+		LogLevel:           "info",
+		BatchSize:          50,
+		MaxPacketSize:      1 << 20,
+		SenderParallelism:  1,
+		SenderPolicy:       "failover",
+		BreakerThreshold:   3,
+		BreakerCooldown:    30 * time.Second,
+		SenderDialTimeout:  5 * time.Second,
+		SenderReadTimeout:  15 * time.Second,
+		SenderWriteTimeout: 15 * time.Second,
+		TLSConnect:         "unencrypted",
+		StateFile:          DefaultStateFile,
+		QueueMaxSize:       100 << 20,
+		QueueMaxAge:        24 * time.Hour,
+		CPUPerCore:         true,
+		FSExcludeTypes:     []string{"squashfs", "iso9660"},
+		FSMountExclude:     `^/(dev|proc|sys|run|snap)(/|$)`,
+		NetIfExclude:       `^(lo|docker\d+|veth.*|br-.*|virbr.*)$`,
+		DevExclude:         `^(loop|ram|zram|fd|sr)\d*$`,
+		HTTPTimeout:        30 * time.Second,
+		MaxRetries:         3,
+		RetryBackoffBase:   1 * time.Second,
+		ProfileEnable:      false,
+		ProfileHTTPPort:    6060,
+		ProfileCPUFile:     "",
+		ProfileMemFile:     "",
+		ProfileTime:        30,
 	}
 }
 
@@ -65,15 +195,87 @@ func (c *Config) Load(cmd *cobra.Command) error {
 	if cmd.Flags().Changed("zabbix-url") {
 		c.ZabbixURL, _ = cmd.Flags().GetString("zabbix-url")
 	}
+	if cmd.Flags().Changed("zabbix-server") {
+		c.ZabbixServers, _ = cmd.Flags().GetStringSlice("zabbix-server")
+	}
+	if cmd.Flags().Changed("sender-policy") {
+		c.SenderPolicy, _ = cmd.Flags().GetString("sender-policy")
+	}
+	if cmd.Flags().Changed("breaker-threshold") {
+		c.BreakerThreshold, _ = cmd.Flags().GetInt("breaker-threshold")
+	}
+	if cmd.Flags().Changed("breaker-cooldown") {
+		cooldownSec, _ := cmd.Flags().GetInt("breaker-cooldown")
+		c.BreakerCooldown = time.Duration(cooldownSec) * time.Second
+	}
 	if cmd.Flags().Changed("zabbix-user") {
 		c.ZabbixUser, _ = cmd.Flags().GetString("zabbix-user")
 	}
 	if cmd.Flags().Changed("zabbix-password") {
 		c.ZabbixPassword, _ = cmd.Flags().GetString("zabbix-password")
 	}
+	if cmd.Flags().Changed("zabbix-api-token") {
+		c.ZabbixAPIToken, _ = cmd.Flags().GetString("zabbix-api-token")
+	}
 	if cmd.Flags().Changed("zabbix-host") {
 		c.ZabbixHost, _ = cmd.Flags().GetString("zabbix-host")
 	}
+	if cmd.Flags().Changed("auto-register") {
+		c.AutoRegister, _ = cmd.Flags().GetBool("auto-register")
+	}
+	if cmd.Flags().Changed("host-groups") {
+		c.HostGroups, _ = cmd.Flags().GetStringSlice("host-groups")
+	}
+	if cmd.Flags().Changed("host-templates") {
+		c.HostTemplates, _ = cmd.Flags().GetStringSlice("host-templates")
+	}
+	if cmd.Flags().Changed("host-visible-name") {
+		c.HostVisibleName, _ = cmd.Flags().GetString("host-visible-name")
+	}
+	if cmd.Flags().Changed("host-tags") {
+		c.HostTags, _ = cmd.Flags().GetStringSlice("host-tags")
+	}
+	if cmd.Flags().Changed("host-interface") {
+		c.HostInterface, _ = cmd.Flags().GetString("host-interface")
+	}
+	if cmd.Flags().Changed("create-triggers") {
+		c.CreateTriggers, _ = cmd.Flags().GetBool("create-triggers")
+	}
+	if cmd.Flags().Changed("triggers-file") {
+		c.TriggersFile, _ = cmd.Flags().GetString("triggers-file")
+	}
+	if cmd.Flags().Changed("reconcile-items") {
+		c.ReconcileItems, _ = cmd.Flags().GetBool("reconcile-items")
+	}
+	if cmd.Flags().Changed("reconcile-prune") {
+		c.ReconcilePrune, _ = cmd.Flags().GetString("reconcile-prune")
+	}
+	if cmd.Flags().Changed("reconcile-dry-run") {
+		c.ReconcileDryRun, _ = cmd.Flags().GetBool("reconcile-dry-run")
+	}
+	if cmd.Flags().Changed("migrate-legacy-items") {
+		c.MigrateLegacyItems, _ = cmd.Flags().GetBool("migrate-legacy-items")
+	}
+	if cmd.Flags().Changed("mode") {
+		c.Mode, _ = cmd.Flags().GetString("mode")
+	}
+	if cmd.Flags().Changed("host-metadata") {
+		c.HostMetadata, _ = cmd.Flags().GetString("host-metadata")
+	}
+	if cmd.Flags().Changed("active-refresh") {
+		refreshSec, _ := cmd.Flags().GetInt("active-refresh")
+		c.ActiveRefresh = time.Duration(refreshSec) * time.Second
+	}
+	if cmd.Flags().Changed("passive-listen") {
+		c.PassiveListen, _ = cmd.Flags().GetString("passive-listen")
+	}
+	if cmd.Flags().Changed("allowed-servers") {
+		c.AllowedServers, _ = cmd.Flags().GetStringSlice("allowed-servers")
+	}
+	if cmd.Flags().Changed("passive-timeout") {
+		timeoutSec, _ := cmd.Flags().GetInt("passive-timeout")
+		c.PassiveTimeout = time.Duration(timeoutSec) * time.Second
+	}
 	if cmd.Flags().Changed("interval") {
 		intervalSec, _ := cmd.Flags().GetInt("interval")
 		c.Interval = time.Duration(intervalSec) * time.Second
@@ -84,6 +286,97 @@ func (c *Config) Load(cmd *cobra.Command) error {
 	if cmd.Flags().Changed("batch-size") {
 		c.BatchSize, _ = cmd.Flags().GetInt("batch-size")
 	}
+	if cmd.Flags().Changed("max-packet-size") {
+		c.MaxPacketSize, _ = cmd.Flags().GetInt("max-packet-size")
+	}
+	if cmd.Flags().Changed("sender-parallelism") {
+		c.SenderParallelism, _ = cmd.Flags().GetInt("sender-parallelism")
+	}
+	if cmd.Flags().Changed("sender-compress") {
+		c.SenderCompress, _ = cmd.Flags().GetBool("sender-compress")
+	}
+	if cmd.Flags().Changed("sender-dial-timeout") {
+		timeoutSec, _ := cmd.Flags().GetInt("sender-dial-timeout")
+		c.SenderDialTimeout = time.Duration(timeoutSec) * time.Second
+	}
+	if cmd.Flags().Changed("sender-read-timeout") {
+		timeoutSec, _ := cmd.Flags().GetInt("sender-read-timeout")
+		c.SenderReadTimeout = time.Duration(timeoutSec) * time.Second
+	}
+	if cmd.Flags().Changed("sender-write-timeout") {
+		timeoutSec, _ := cmd.Flags().GetInt("sender-write-timeout")
+		c.SenderWriteTimeout = time.Duration(timeoutSec) * time.Second
+	}
+	if cmd.Flags().Changed("sender-pool-size") {
+		c.SenderPoolSize, _ = cmd.Flags().GetInt("sender-pool-size")
+	}
+	if cmd.Flags().Changed("tls-connect") {
+		c.TLSConnect, _ = cmd.Flags().GetString("tls-connect")
+	}
+	if cmd.Flags().Changed("tls-ca-file") {
+		c.TLSCAFile, _ = cmd.Flags().GetString("tls-ca-file")
+	}
+	if cmd.Flags().Changed("tls-cert-file") {
+		c.TLSCertFile, _ = cmd.Flags().GetString("tls-cert-file")
+	}
+	if cmd.Flags().Changed("tls-key-file") {
+		c.TLSKeyFile, _ = cmd.Flags().GetString("tls-key-file")
+	}
+	if cmd.Flags().Changed("tls-server-cert-issuer") {
+		c.TLSServerCertIssuer, _ = cmd.Flags().GetString("tls-server-cert-issuer")
+	}
+	if cmd.Flags().Changed("tls-server-cert-subject") {
+		c.TLSServerCertSubject, _ = cmd.Flags().GetString("tls-server-cert-subject")
+	}
+	if cmd.Flags().Changed("sender-bisect") {
+		c.SenderBisect, _ = cmd.Flags().GetBool("sender-bisect")
+	}
+	if cmd.Flags().Changed("state-file") {
+		c.StateFile, _ = cmd.Flags().GetString("state-file")
+	}
+	if cmd.Flags().Changed("queue-dir") {
+		c.QueueDir, _ = cmd.Flags().GetString("queue-dir")
+	}
+	if cmd.Flags().Changed("queue-max-size") {
+		c.QueueMaxSize, _ = cmd.Flags().GetInt64("queue-max-size")
+	}
+	if cmd.Flags().Changed("queue-max-age") {
+		maxAgeSec, _ := cmd.Flags().GetInt("queue-max-age")
+		c.QueueMaxAge = time.Duration(maxAgeSec) * time.Second
+	}
+	if cmd.Flags().Changed("sources") {
+		c.Sources, _ = cmd.Flags().GetStringSlice("sources")
+	}
+	if cmd.Flags().Changed("disable-sources") {
+		c.DisabledSources, _ = cmd.Flags().GetStringSlice("disable-sources")
+	}
+	if cmd.Flags().Changed("cpu-per-core") {
+		c.CPUPerCore, _ = cmd.Flags().GetBool("cpu-per-core")
+	}
+	if cmd.Flags().Changed("fs-types") {
+		c.FSTypes, _ = cmd.Flags().GetStringSlice("fs-types")
+	}
+	if cmd.Flags().Changed("fs-exclude-types") {
+		c.FSExcludeTypes, _ = cmd.Flags().GetStringSlice("fs-exclude-types")
+	}
+	if cmd.Flags().Changed("fs-mount-include") {
+		c.FSMountInclude, _ = cmd.Flags().GetString("fs-mount-include")
+	}
+	if cmd.Flags().Changed("fs-mount-exclude") {
+		c.FSMountExclude, _ = cmd.Flags().GetString("fs-mount-exclude")
+	}
+	if cmd.Flags().Changed("net-if-include") {
+		c.NetIfInclude, _ = cmd.Flags().GetString("net-if-include")
+	}
+	if cmd.Flags().Changed("net-if-exclude") {
+		c.NetIfExclude, _ = cmd.Flags().GetString("net-if-exclude")
+	}
+	if cmd.Flags().Changed("dev-include") {
+		c.DevInclude, _ = cmd.Flags().GetString("dev-include")
+	}
+	if cmd.Flags().Changed("dev-exclude") {
+		c.DevExclude, _ = cmd.Flags().GetString("dev-exclude")
+	}
 	if cmd.Flags().Changed("profile") {
 		c.ProfileEnable, _ = cmd.Flags().GetBool("profile")
 	}
@@ -108,20 +401,107 @@ func (c *Config) loadFromEnv() {
 	if url := os.Getenv("ZABBIX_URL"); url != "" {
 		c.ZabbixURL = url
 	}
+	if servers := os.Getenv("ZABBIX_SERVER"); servers != "" {
+		c.ZabbixServers = splitList(servers)
+	}
+	if policy := os.Getenv("SENDER_POLICY"); policy != "" {
+		c.SenderPolicy = policy
+	}
+	if thresholdStr := os.Getenv("BREAKER_THRESHOLD"); thresholdStr != "" {
+		if threshold, err := strconv.Atoi(thresholdStr); err == nil {
+			c.BreakerThreshold = threshold
+		}
+	}
+	if cooldownStr := os.Getenv("BREAKER_COOLDOWN"); cooldownStr != "" {
+		if cooldownSec, err := strconv.Atoi(cooldownStr); err == nil {
+			c.BreakerCooldown = time.Duration(cooldownSec) * time.Second
+		}
+	}
 	if user := os.Getenv("ZABBIX_USER"); user != "" {
 		c.ZabbixUser = user
 	}
 	if pass := os.Getenv("ZABBIX_PASSWORD"); pass != "" {
 		c.ZabbixPassword = pass
 	}
+	if token := os.Getenv("ZABBIX_API_TOKEN"); token != "" {
+		c.ZabbixAPIToken = token
+	}
 	if host := os.Getenv("ZABBIX_HOST"); host != "" {
 		c.ZabbixHost = host
 	}
+	if autoRegisterStr := os.Getenv("AUTO_REGISTER"); autoRegisterStr != "" {
+		if autoRegister, err := strconv.ParseBool(autoRegisterStr); err == nil {
+			c.AutoRegister = autoRegister
+		}
+	}
+	if groups := os.Getenv("HOST_GROUPS"); groups != "" {
+		c.HostGroups = splitList(groups)
+	}
+	if templates := os.Getenv("HOST_TEMPLATES"); templates != "" {
+		c.HostTemplates = splitList(templates)
+	}
+	if name := os.Getenv("HOST_VISIBLE_NAME"); name != "" {
+		c.HostVisibleName = name
+	}
+	if tags := os.Getenv("HOST_TAGS"); tags != "" {
+		c.HostTags = splitList(tags)
+	}
+	if iface := os.Getenv("HOST_INTERFACE"); iface != "" {
+		c.HostInterface = iface
+	}
+	if createStr := os.Getenv("CREATE_TRIGGERS"); createStr != "" {
+		if create, err := strconv.ParseBool(createStr); err == nil {
+			c.CreateTriggers = create
+		}
+	}
+	if triggersFile := os.Getenv("TRIGGERS_FILE"); triggersFile != "" {
+		c.TriggersFile = triggersFile
+	}
+	if reconcileStr := os.Getenv("RECONCILE_ITEMS"); reconcileStr != "" {
+		if reconcile, err := strconv.ParseBool(reconcileStr); err == nil {
+			c.ReconcileItems = reconcile
+		}
+	}
+	if prune := os.Getenv("RECONCILE_PRUNE"); prune != "" {
+		c.ReconcilePrune = prune
+	}
+	if dryRunStr := os.Getenv("RECONCILE_DRY_RUN"); dryRunStr != "" {
+		if dryRun, err := strconv.ParseBool(dryRunStr); err == nil {
+			c.ReconcileDryRun = dryRun
+		}
+	}
+	if migrateStr := os.Getenv("MIGRATE_LEGACY_ITEMS"); migrateStr != "" {
+		if migrate, err := strconv.ParseBool(migrateStr); err == nil {
+			c.MigrateLegacyItems = migrate
+		}
+	}
 	if intervalStr := os.Getenv("INTERVAL"); intervalStr != "" {
 		if intervalSec, err := strconv.Atoi(intervalStr); err == nil {
 			c.Interval = time.Duration(intervalSec) * time.Second
 		}
 	}
+	if mode := os.Getenv("MODE"); mode != "" {
+		c.Mode = mode
+	}
+	if metadata := os.Getenv("HOST_METADATA"); metadata != "" {
+		c.HostMetadata = metadata
+	}
+	if refreshStr := os.Getenv("ACTIVE_REFRESH"); refreshStr != "" {
+		if refreshSec, err := strconv.Atoi(refreshStr); err == nil {
+			c.ActiveRefresh = time.Duration(refreshSec) * time.Second
+		}
+	}
+	if listen := os.Getenv("PASSIVE_LISTEN"); listen != "" {
+		c.PassiveListen = listen
+	}
+	if servers := os.Getenv("ALLOWED_SERVERS"); servers != "" {
+		c.AllowedServers = splitList(servers)
+	}
+	if timeoutStr := os.Getenv("PASSIVE_TIMEOUT"); timeoutStr != "" {
+		if timeoutSec, err := strconv.Atoi(timeoutStr); err == nil {
+			c.PassiveTimeout = time.Duration(timeoutSec) * time.Second
+		}
+	}
 	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
 		c.LogLevel = logLevel
 	}
@@ -130,6 +510,116 @@ func (c *Config) loadFromEnv() {
 			c.BatchSize = batchSize
 		}
 	}
+	if maxPacketSizeStr := os.Getenv("MAX_PACKET_SIZE"); maxPacketSizeStr != "" {
+		if maxPacketSize, err := strconv.Atoi(maxPacketSizeStr); err == nil {
+			c.MaxPacketSize = maxPacketSize
+		}
+	}
+	if parallelismStr := os.Getenv("SENDER_PARALLELISM"); parallelismStr != "" {
+		if parallelism, err := strconv.Atoi(parallelismStr); err == nil {
+			c.SenderParallelism = parallelism
+		}
+	}
+	if compressStr := os.Getenv("SENDER_COMPRESS"); compressStr != "" {
+		if compress, err := strconv.ParseBool(compressStr); err == nil {
+			c.SenderCompress = compress
+		}
+	}
+	if timeoutStr := os.Getenv("SENDER_DIAL_TIMEOUT"); timeoutStr != "" {
+		if timeoutSec, err := strconv.Atoi(timeoutStr); err == nil {
+			c.SenderDialTimeout = time.Duration(timeoutSec) * time.Second
+		}
+	}
+	if timeoutStr := os.Getenv("SENDER_READ_TIMEOUT"); timeoutStr != "" {
+		if timeoutSec, err := strconv.Atoi(timeoutStr); err == nil {
+			c.SenderReadTimeout = time.Duration(timeoutSec) * time.Second
+		}
+	}
+	if timeoutStr := os.Getenv("SENDER_WRITE_TIMEOUT"); timeoutStr != "" {
+		if timeoutSec, err := strconv.Atoi(timeoutStr); err == nil {
+			c.SenderWriteTimeout = time.Duration(timeoutSec) * time.Second
+		}
+	}
+	if poolSizeStr := os.Getenv("SENDER_POOL_SIZE"); poolSizeStr != "" {
+		if poolSize, err := strconv.Atoi(poolSizeStr); err == nil {
+			c.SenderPoolSize = poolSize
+		}
+	}
+	if tlsConnect := os.Getenv("TLS_CONNECT"); tlsConnect != "" {
+		c.TLSConnect = tlsConnect
+	}
+	if tlsCAFile := os.Getenv("TLS_CA_FILE"); tlsCAFile != "" {
+		c.TLSCAFile = tlsCAFile
+	}
+	if tlsCertFile := os.Getenv("TLS_CERT_FILE"); tlsCertFile != "" {
+		c.TLSCertFile = tlsCertFile
+	}
+	if tlsKeyFile := os.Getenv("TLS_KEY_FILE"); tlsKeyFile != "" {
+		c.TLSKeyFile = tlsKeyFile
+	}
+	if issuer := os.Getenv("TLS_SERVER_CERT_ISSUER"); issuer != "" {
+		c.TLSServerCertIssuer = issuer
+	}
+	if subject := os.Getenv("TLS_SERVER_CERT_SUBJECT"); subject != "" {
+		c.TLSServerCertSubject = subject
+	}
+	if bisectStr := os.Getenv("SENDER_BISECT"); bisectStr != "" {
+		if bisect, err := strconv.ParseBool(bisectStr); err == nil {
+			c.SenderBisect = bisect
+		}
+	}
+	// Пустое значение отключает сохранение состояния
+	if stateFile, ok := os.LookupEnv("STATE_FILE"); ok {
+		c.StateFile = stateFile
+	}
+	if queueDir := os.Getenv("QUEUE_DIR"); queueDir != "" {
+		c.QueueDir = queueDir
+	}
+	if maxSizeStr := os.Getenv("QUEUE_MAX_SIZE"); maxSizeStr != "" {
+		if maxSize, err := strconv.ParseInt(maxSizeStr, 10, 64); err == nil {
+			c.QueueMaxSize = maxSize
+		}
+	}
+	if maxAgeStr := os.Getenv("QUEUE_MAX_AGE"); maxAgeStr != "" {
+		if maxAgeSec, err := strconv.Atoi(maxAgeStr); err == nil {
+			c.QueueMaxAge = time.Duration(maxAgeSec) * time.Second
+		}
+	}
+	if sources := os.Getenv("SOURCES"); sources != "" {
+		c.Sources = splitList(sources)
+	}
+	if disabled := os.Getenv("DISABLE_SOURCES"); disabled != "" {
+		c.DisabledSources = splitList(disabled)
+	}
+	if perCoreStr := os.Getenv("CPU_PER_CORE"); perCoreStr != "" {
+		if perCore, err := strconv.ParseBool(perCoreStr); err == nil {
+			c.CPUPerCore = perCore
+		}
+	}
+	if fsTypes := os.Getenv("FS_TYPES"); fsTypes != "" {
+		c.FSTypes = splitList(fsTypes)
+	}
+	if fsExcludeTypes := os.Getenv("FS_EXCLUDE_TYPES"); fsExcludeTypes != "" {
+		c.FSExcludeTypes = splitList(fsExcludeTypes)
+	}
+	if include := os.Getenv("FS_MOUNT_INCLUDE"); include != "" {
+		c.FSMountInclude = include
+	}
+	if exclude := os.Getenv("FS_MOUNT_EXCLUDE"); exclude != "" {
+		c.FSMountExclude = exclude
+	}
+	if include := os.Getenv("NET_IF_INCLUDE"); include != "" {
+		c.NetIfInclude = include
+	}
+	if exclude := os.Getenv("NET_IF_EXCLUDE"); exclude != "" {
+		c.NetIfExclude = exclude
+	}
+	if include := os.Getenv("DEV_INCLUDE"); include != "" {
+		c.DevInclude = include
+	}
+	if exclude := os.Getenv("DEV_EXCLUDE"); exclude != "" {
+		c.DevExclude = exclude
+	}
 	if profileStr := os.Getenv("PROFILE_ENABLE"); profileStr != "" {
 		if profile, err := strconv.ParseBool(profileStr); err == nil {
 			c.ProfileEnable = profile
@@ -153,26 +643,137 @@ func (c *Config) loadFromEnv() {
 	}
 }
 
+// splitList разбивает строку со списком значений через запятую
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 // Validate проверяет корректность конфигурации
 func (c *Config) Validate() error {
 	if c.ZabbixURL == "" {
 		return fmt.Errorf("zabbix URL is required")
 	}
-	if c.ZabbixUser == "" {
+	if c.ZabbixAPIToken == "" && c.ZabbixUser == "" {
 		return fmt.Errorf("zabbix user is required")
 	}
-	if c.ZabbixPassword == "" {
+	if c.ZabbixAPIToken == "" && c.ZabbixPassword == "" {
 		return fmt.Errorf("zabbix password is required")
 	}
 	if c.ZabbixHost == "" {
 		return fmt.Errorf("zabbix host is required")
 	}
+	if c.ReconcilePrune != zabbix.PruneDisable && c.ReconcilePrune != zabbix.PruneDelete {
+		return fmt.Errorf("invalid reconcile prune action: %s", c.ReconcilePrune)
+	}
+	if c.AutoRegister {
+		if c.Mode != ModeTrapper {
+			return fmt.Errorf("auto-register requires %s mode", ModeTrapper)
+		}
+		if len(c.HostGroups) == 0 {
+			return fmt.Errorf("at least one host group is required for auto-register")
+		}
+		if _, err := zabbix.ParseHostTags(c.HostTags); err != nil {
+			return err
+		}
+	}
 	if c.Interval <= 0 {
 		return fmt.Errorf("interval must be positive")
 	}
 	if c.BatchSize <= 0 {
 		return fmt.Errorf("batch size must be positive")
 	}
+	if c.MaxPacketSize <= 0 {
+		return fmt.Errorf("max packet size must be positive")
+	}
+	if c.SenderParallelism <= 0 {
+		return fmt.Errorf("sender parallelism must be positive")
+	}
+
+	for _, server := range c.ZabbixServers {
+		if _, _, err := zabbix.ParseAddress(server); err != nil {
+			return err
+		}
+	}
+	if c.Mode != ModeTrapper && c.Mode != ModeActive && c.Mode != ModePassive {
+		return fmt.Errorf("invalid mode: %s", c.Mode)
+	}
+	if c.Mode == ModePassive && c.PassiveListen == "" {
+		return fmt.Errorf("passive listen address is required in passive mode")
+	}
+	if c.PassiveListen != "" {
+		if len(c.AllowedServers) == 0 {
+			return fmt.Errorf("allowed servers are required for passive checks")
+		}
+		if c.PassiveTimeout <= 0 || c.PassiveTimeout > 30*time.Second {
+			return fmt.Errorf("passive timeout must be between 1 and 30 seconds")
+		}
+	}
+	if c.ActiveRefresh <= 0 {
+		return fmt.Errorf("active checks refresh interval must be positive")
+	}
+	if c.Mode == ModeActive && c.QueueDir != "" {
+		return fmt.Errorf("disk queue is not supported in active mode")
+	}
+	if c.QueueMaxSize <= 0 {
+		return fmt.Errorf("queue max size must be positive")
+	}
+	if c.QueueMaxAge <= 0 {
+		return fmt.Errorf("queue max age must be positive")
+	}
+	if c.SenderPolicy != zabbix.PolicyFailover && c.SenderPolicy != zabbix.PolicyFanout {
+		return fmt.Errorf("invalid sender policy: %s", c.SenderPolicy)
+	}
+	if c.BreakerThreshold <= 0 {
+		return fmt.Errorf("breaker threshold must be positive")
+	}
+	if c.BreakerCooldown <= 0 {
+		return fmt.Errorf("breaker cooldown must be positive")
+	}
+	if c.SenderDialTimeout <= 0 || c.SenderReadTimeout <= 0 || c.SenderWriteTimeout <= 0 {
+		return fmt.Errorf("sender timeouts must be positive")
+	}
+	if c.SenderPoolSize < 0 {
+		return fmt.Errorf("sender pool size must not be negative")
+	}
+
+	// Проверяем настройки шифрования
+	switch c.TLSConnect {
+	case "unencrypted":
+	case "cert":
+		if c.TLSCAFile == "" || c.TLSCertFile == "" || c.TLSKeyFile == "" {
+			return fmt.Errorf("TLS CA file, certificate file and key file are required for TLS connect mode cert")
+		}
+	case zabbix.TLSConnectPSK:
+		return fmt.Errorf("TLS connect mode psk is not supported, use cert")
+	default:
+		return fmt.Errorf("invalid TLS connect mode: %s", c.TLSConnect)
+	}
+
+	// Проверяем фильтры файловых систем
+	if _, err := regexp.Compile(c.FSMountInclude); err != nil {
+		return fmt.Errorf("invalid mountpoint include pattern: %w", err)
+	}
+	if _, err := regexp.Compile(c.FSMountExclude); err != nil {
+		return fmt.Errorf("invalid mountpoint exclude pattern: %w", err)
+	}
+	if _, err := regexp.Compile(c.NetIfInclude); err != nil {
+		return fmt.Errorf("invalid interface include pattern: %w", err)
+	}
+	if _, err := regexp.Compile(c.NetIfExclude); err != nil {
+		return fmt.Errorf("invalid interface exclude pattern: %w", err)
+	}
+	if _, err := regexp.Compile(c.DevInclude); err != nil {
+		return fmt.Errorf("invalid device include pattern: %w", err)
+	}
+	if _, err := regexp.Compile(c.DevExclude); err != nil {
+		return fmt.Errorf("invalid device exclude pattern: %w", err)
+	}
 
 	// Проверяем уровень логирования
 	validLevels := map[string]bool{
@@ -201,12 +802,64 @@ func (c *Config) Validate() error {
 // AddFlags добавляет флаги в cobra команду
 func AddFlags(cmd *cobra.Command) {
 	cmd.Flags().String("zabbix-url", "", "Zabbix API URL")
+	cmd.Flags().StringSlice("zabbix-server", nil, "Zabbix trapper addresses host:port (default: host from Zabbix URL, port 10051)")
+	cmd.Flags().String("sender-policy", "failover", "Delivery policy for several trapper addresses (failover, fanout)")
+	cmd.Flags().Int("breaker-threshold", 3, "Consecutive failures after which a trapper address is skipped")
+	cmd.Flags().Int("breaker-cooldown", 30, "Seconds to skip an unavailable trapper address")
 	cmd.Flags().String("zabbix-user", "", "Zabbix username")
 	cmd.Flags().String("zabbix-password", "", "Zabbix password")
+	cmd.Flags().String("zabbix-api-token", "", "Zabbix API token used instead of user and password")
 	cmd.Flags().String("zabbix-host", "", "Host name in Zabbix")
+	cmd.Flags().Bool("auto-register", false, "Create the host if it is missing and keep its tags and templates in sync")
+	cmd.Flags().StringSlice("host-groups", nil, "Host group names for auto-register")
+	cmd.Flags().StringSlice("host-templates", nil, "Template names to link for auto-register")
+	cmd.Flags().String("host-visible-name", "", "Visible host name for auto-register")
+	cmd.Flags().StringSlice("host-tags", nil, "Host tags tag=value for auto-register")
+	cmd.Flags().String("host-interface", "", "Agent interface address host[:port] for auto-register (default: 127.0.0.1:10050)")
+	cmd.Flags().Bool("create-triggers", true, "Create and update built-in triggers on startup")
+	cmd.Flags().String("triggers-file", "", "JSON file with additional or overridden trigger definitions")
+	cmd.Flags().Bool("reconcile-items", false, "Update items that differ from the catalogue and prune obsolete managed items")
+	cmd.Flags().String("reconcile-prune", zabbix.PruneDisable, "Action for obsolete managed items (disable, delete)")
+	cmd.Flags().Bool("reconcile-dry-run", false, "Only log planned host, item and trigger changes")
+	cmd.Flags().Bool("migrate-legacy-items", false, "Delete items of previous versions that conflict with discovery, with their history")
+	cmd.Flags().String("mode", ModeTrapper, "Operating mode (trapper, active, passive)")
+	cmd.Flags().String("host-metadata", "", "Host metadata sent with active checks request")
+	cmd.Flags().Int("active-refresh", 120, "Active checks list refresh interval in seconds")
+	cmd.Flags().String("passive-listen", "", "Address to answer passive checks on, e.g. 0.0.0.0:10050 (default: disabled)")
+	cmd.Flags().StringSlice("allowed-servers", nil, "Addresses, CIDR networks or host names allowed to request passive checks")
+	cmd.Flags().Int("passive-timeout", 3, "Passive check request timeout in seconds")
 	cmd.Flags().Int("interval", 10, "Collection interval in seconds")
 	cmd.Flags().String("log-level", "info", "Log level (debug, info, warn, error)")
 	cmd.Flags().Int("batch-size", 50, "Batch size for sending metrics")
+	cmd.Flags().Int("max-packet-size", 1<<20, "Maximum size of a sender packet in bytes")
+	cmd.Flags().Int("sender-parallelism", 1, "Number of sender packets sent concurrently")
+	cmd.Flags().Bool("sender-compress", false, "Compress sender packets with zlib (Zabbix 4.0+)")
+	cmd.Flags().Int("sender-dial-timeout", 5, "Sender connection timeout in seconds")
+	cmd.Flags().Int("sender-read-timeout", 15, "Sender reply read timeout in seconds")
+	cmd.Flags().Int("sender-write-timeout", 15, "Sender packet write timeout in seconds")
+	cmd.Flags().Int("sender-pool-size", 0, "Number of idle sender connections to keep open (0 disables pooling)")
+	cmd.Flags().String("tls-connect", "unencrypted", "How to connect to Zabbix server (unencrypted, cert)")
+	cmd.Flags().String("tls-ca-file", "", "File with CA certificates for server certificate verification")
+	cmd.Flags().String("tls-cert-file", "", "File with client certificate")
+	cmd.Flags().String("tls-key-file", "", "File with client private key")
+	cmd.Flags().String("tls-server-cert-issuer", "", "Allowed server certificate issuer")
+	cmd.Flags().String("tls-server-cert-subject", "", "Allowed server certificate subject")
+	cmd.Flags().Bool("sender-bisect", false, "Find values rejected by Zabbix by splitting packets (may duplicate accepted values)")
+	cmd.Flags().String("state-file", DefaultStateFile, "File to persist counter state between restarts (empty: disabled)")
+	cmd.Flags().String("queue-dir", "", "Directory for the disk queue of unsent metrics (default: disabled)")
+	cmd.Flags().Int64("queue-max-size", 100<<20, "Maximum size of the disk queue in bytes")
+	cmd.Flags().Int("queue-max-age", 86400, "Maximum age of queued metrics in seconds")
+	cmd.Flags().StringSlice("sources", nil, "Metric sources to enable (default: all)")
+	cmd.Flags().StringSlice("disable-sources", nil, "Metric sources to disable")
+	cmd.Flags().Bool("cpu-per-core", true, "Collect per-core CPU utilization")
+	cmd.Flags().StringSlice("fs-types", nil, "Filesystem types to monitor (default: all)")
+	cmd.Flags().StringSlice("fs-exclude-types", nil, "Filesystem types to skip")
+	cmd.Flags().String("fs-mount-include", "", "Regular expression for mountpoints to monitor")
+	cmd.Flags().String("fs-mount-exclude", "", "Regular expression for mountpoints to skip")
+	cmd.Flags().String("net-if-include", "", "Regular expression for network interfaces to monitor")
+	cmd.Flags().String("net-if-exclude", "", "Regular expression for network interfaces to skip")
+	cmd.Flags().String("dev-include", "", "Regular expression for block devices to monitor")
+	cmd.Flags().String("dev-exclude", "", "Regular expression for block devices to skip")
 
 	// Флаги профилирования
 	cmd.Flags().Bool("profile", false, "Enable profiling")
@@ -0,0 +1,251 @@
+package passive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"zabbix_mon/internal/collector"
+	"zabbix_mon/pkg/zabbix"
+
+	"go.uber.org/zap"
+)
+
+// notSupported префикс ответа на неподдерживаемую проверку
+const notSupported = "ZBX_NOTSUPPORTED\x00"
+
+// maxConnections максимальное количество одновременно обрабатываемых запросов
+const maxConnections = 16
+
+// maxKeyLength максимальная длина ключа в запросе с заголовком ZBXD или без него
+const maxKeyLength = 4 << 10
+
+// Config содержит настройки пассивных проверок
+type Config struct {
+	ListenAddr     string        // адрес для входящих соединений, например 0.0.0.0:10050
+	AllowedServers []string      // IP, подсети CIDR или имена хостов, которым разрешены запросы
+	Timeout        time.Duration // таймаут обработки одного запроса
+	HostName       string        // значение agent.hostname
+}
+
+// MetricsFunc возвращает последний собранный набор метрик или nil, если сбор еще не выполнялся
+type MetricsFunc func() *collector.MetricSet
+
+// Server отвечает на пассивные проверки сервера Zabbix значениями последнего цикла сбора
+type Server struct {
+	cfg     Config
+	metrics MetricsFunc
+	logger  *zap.Logger
+
+	networks []*net.IPNet
+	hosts    []string
+
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// New создает сервер пассивных проверок
+func New(cfg Config, metrics MetricsFunc, logger *zap.Logger) (*Server, error) {
+	s := &Server{
+		cfg:     cfg,
+		metrics: metrics,
+		logger:  logger,
+	}
+
+	for _, entry := range cfg.AllowedServers {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			s.networks = append(s.networks, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			s.networks = append(s.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		s.hosts = append(s.hosts, entry)
+	}
+
+	if len(s.networks) == 0 && len(s.hosts) == 0 {
+		return nil, errors.New("allowed servers list is empty")
+	}
+
+	return s, nil
+}
+
+// Start начинает прием соединений. Сервер останавливается при отмене ctx.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.ListenAddr, err)
+	}
+	s.listener = listener
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	s.wg.Add(1)
+	go s.acceptLoop(ctx)
+
+	s.logger.Info("Passive checks listener started",
+		zap.String("address", listener.Addr().String()),
+		zap.Strings("allowed_servers", s.cfg.AllowedServers))
+	return nil
+}
+
+// Wait ожидает завершения обработки всех соединений
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// acceptLoop принимает соединения и обрабатывает их с ограничением параллельности
+func (s *Server) acceptLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	semaphore := make(chan struct{}, maxConnections)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Warn("Failed to accept connection", zap.Error(err))
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		semaphore <- struct{}{}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() { <-semaphore }()
+			s.handle(ctx, conn)
+		}()
+	}
+}
+
+// handle обрабатывает один запрос пассивной проверки
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	// Весь запрос, включая проверку доступа, ограничен таймаутом
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	remote, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	if !s.allowed(ctx, remote) {
+		s.logger.Warn("Rejected connection from unauthorized address", zap.String("address", remote))
+		return
+	}
+
+	key, err := readRequest(bufio.NewReaderSize(conn, maxKeyLength))
+	if err != nil {
+		s.logger.Debug("Failed to read passive check request",
+			zap.String("address", remote),
+			zap.Error(err))
+		return
+	}
+
+	value := s.evaluate(key)
+	s.logger.Debug("Passive check processed",
+		zap.String("address", remote),
+		zap.String("key", key),
+		zap.Bool("supported", !strings.HasPrefix(value, notSupported)))
+
+	frame, err := zabbix.EncodeFrame([]byte(value), false)
+	if err != nil {
+		return
+	}
+	if _, err := conn.Write(frame); err != nil {
+		s.logger.Debug("Failed to send passive check reply",
+			zap.String("address", remote),
+			zap.Error(err))
+	}
+}
+
+// readRequest читает ключ проверки с заголовком ZBXD или без него (старые версии сервера)
+func readRequest(reader *bufio.Reader) (string, error) {
+	header, err := reader.Peek(4)
+	if err == nil && string(header) == "ZBXD" {
+		payload, err := zabbix.ReadFrameLimit(reader, maxKeyLength)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(payload), "\r\n"), nil
+	}
+
+	line, err := reader.ReadSlice('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return "", fmt.Errorf("request exceeds %d bytes", maxKeyLength)
+		}
+		return "", err
+	}
+	return string(bytes.TrimRight(line, "\r\n")), nil
+}
+
+// evaluate возвращает значение проверки или ответ ZBX_NOTSUPPORTED с причиной
+func (s *Server) evaluate(key string) string {
+	switch key {
+	case "agent.ping":
+		return "1"
+	case "agent.hostname":
+		return s.cfg.HostName
+	case "agent.version":
+		return zabbix.AgentVersion
+	}
+
+	metrics := s.metrics()
+	if metrics == nil {
+		return notSupported + "Metrics have not been collected yet."
+	}
+
+	value, ok := metrics.Get(key)
+	if !ok {
+		return notSupported + "Unsupported item key."
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// allowed проверяет адрес клиента по списку разрешенных серверов.
+// Имена хостов разрешаются при каждом соединении.
+func (s *Server) allowed(ctx context.Context, address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range s.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	for _, host := range s.hosts {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			s.logger.Debug("Failed to resolve allowed server", zap.String("host", host), zap.Error(err))
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.IP.Equal(ip) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package passive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"zabbix_mon/pkg/zabbix"
+)
+
+func TestReadRequest(t *testing.T) {
+	frame := func(payload string) []byte {
+		data, err := zabbix.EncodeFrame([]byte(payload), false)
+		if err != nil {
+			t.Fatalf("EncodeFrame: %v", err)
+		}
+		return data
+	}
+
+	// Заголовок объявляет 1 ГиБ данных, которые не присылаются
+	huge := frame("")
+	binary.LittleEndian.PutUint32(huge[5:], 1<<30)
+
+	tests := []struct {
+		name    string
+		request []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "framed", request: frame("agent.ping"), want: "agent.ping"},
+		{name: "framed with newline", request: frame("system.cpu.num\n"), want: "system.cpu.num"},
+		{name: "plain", request: []byte("agent.version\n"), want: "agent.version"},
+		{name: "plain without newline", request: []byte("agent.hostname"), want: "agent.hostname"},
+		{name: "framed at limit", request: frame(strings.Repeat("k", maxKeyLength)), want: strings.Repeat("k", maxKeyLength)},
+		{name: "framed over limit", request: frame(strings.Repeat("k", maxKeyLength+1)), wantErr: true},
+		{name: "framed declares huge size", request: huge, wantErr: true},
+		{name: "plain over limit", request: []byte(strings.Repeat("k", maxKeyLength+1) + "\n"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRequest(bufio.NewReaderSize(bytes.NewReader(tt.request), maxKeyLength))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("readRequest accepted oversized request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readRequest: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readRequest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zabbix_mon/internal/collector"
+	"zabbix_mon/pkg/zabbix"
+
+	"go.uber.org/zap"
+)
+
+// Ключи элементов самомониторинга очереди
+const (
+	KeyBatches = "zabbix_mon.queue.batches"
+	KeyValues  = "zabbix_mon.queue.values"
+	KeySize    = "zabbix_mon.queue.size"
+	KeyAge     = "zabbix_mon.queue.age"
+)
+
+// segmentSize размер сегмента, после которого записи добавляются в новый сегмент.
+// Для небольших очередей сегмент уменьшается, чтобы при превышении лимита
+// удалялась только небольшая часть самых старых значений.
+const segmentSize = 1 << 20
+
+// minSegmentSize минимальный размер сегмента
+const minSegmentSize = 4 << 10
+
+// segmentExt расширение файлов сегментов
+const segmentExt = ".seg"
+
+// record пакет неотправленных значений
+type record struct {
+	Time    time.Time        `json:"time"`
+	Metrics []*zabbix.Metric `json:"metrics"`
+}
+
+// segment файл очереди с несколькими пакетами
+type segment struct {
+	seq     uint64
+	size    int64
+	batches int
+	values  int
+	oldest  time.Time
+	newest  time.Time
+}
+
+// Stats содержит состояние очереди
+type Stats struct {
+	Batches int           `json:"batches"`
+	Values  int           `json:"values"`
+	Size    int64         `json:"size"`
+	Age     time.Duration `json:"age"` // возраст самого старого пакета
+}
+
+// SendFunc отправляет пакет значений. Если возвращает *zabbix.UnsentValuesError,
+// в очереди остаются только недоставленные значения.
+type SendFunc func(ctx context.Context, data []*zabbix.Metric) error
+
+// Queue хранит неотправленные значения на диске и отправляет их по порядку,
+// когда сервер снова доступен. Значения сохраняют исходные метки времени.
+type Queue struct {
+	dir         string
+	maxSize     int64
+	maxAge      time.Duration
+	segmentSize int64
+	logger      *zap.Logger
+
+	mu       sync.Mutex
+	segments []*segment
+	nextSeq  uint64
+
+	// replayMu не дает двум Replay одновременно отправлять один сегмент.
+	// q.mu во время отправки не удерживается, чтобы Push не ждал сеть.
+	replayMu sync.Mutex
+}
+
+// New открывает очередь в каталоге dir и загружает сохраненные сегменты.
+// maxSize ограничивает общий размер сегментов, maxAge - возраст хранимых пакетов.
+func New(dir string, maxSize int64, maxAge time.Duration, logger *zap.Logger) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	q := &Queue{
+		dir:         dir,
+		maxSize:     maxSize,
+		maxAge:      maxAge,
+		segmentSize: segmentSize,
+		logger:      logger,
+		nextSeq:     1,
+	}
+	if maxSize > 0 {
+		q.segmentSize = min(segmentSize, max(maxSize/10, minSegmentSize))
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	q.trim(time.Now())
+
+	stats := q.stats(time.Now())
+	if stats.Batches > 0 {
+		logger.Info("Loaded metric queue",
+			zap.Int("batches", stats.Batches),
+			zap.Int("values", stats.Values),
+			zap.Duration("age", stats.Age))
+	}
+
+	return q, nil
+}
+
+// Keys возвращает ключи элементов самомониторинга очереди
+func Keys() []string {
+	return []string{KeyBatches, KeyValues, KeySize, KeyAge}
+}
+
+// Collect добавляет в набор метрики самомониторинга очереди
+func (q *Queue) Collect(set *collector.MetricSet) {
+	stats := q.Stats()
+	set.Add(KeyBatches, stats.Batches)
+	set.Add(KeyValues, stats.Values)
+	set.Add(KeySize, stats.Size)
+	set.Add(KeyAge, stats.Age.Seconds())
+}
+
+// Stats возвращает текущее состояние очереди
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats(time.Now())
+}
+
+func (q *Queue) stats(now time.Time) Stats {
+	var stats Stats
+	for _, seg := range q.segments {
+		stats.Batches += seg.batches
+		stats.Values += seg.values
+		stats.Size += seg.size
+	}
+	if len(q.segments) > 0 && q.segments[0].batches > 0 {
+		stats.Age = now.Sub(q.segments[0].oldest)
+	}
+	return stats
+}
+
+// Push добавляет пакет значений в конец очереди
+func (q *Queue) Push(data []*zabbix.Metric) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	line, err := json.Marshal(record{Time: now, Metrics: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued batch: %w", err)
+	}
+	line = append(line, '\n')
+
+	var seg *segment
+	if n := len(q.segments); n > 0 && q.segments[n-1].size+int64(len(line)) <= q.segmentSize {
+		seg = q.segments[n-1]
+	} else {
+		seg = &segment{seq: q.nextSeq}
+		q.nextSeq++
+		q.segments = append(q.segments, seg)
+	}
+
+	if err := appendFile(q.path(seg.seq), line); err != nil {
+		if seg.batches == 0 {
+			q.segments = q.segments[:len(q.segments)-1]
+		}
+		return err
+	}
+
+	seg.size += int64(len(line))
+	seg.batches++
+	seg.values += len(data)
+	if seg.oldest.IsZero() {
+		seg.oldest = now
+	}
+	seg.newest = now
+
+	q.trim(now)
+	return nil
+}
+
+// Replay отправляет пакеты очереди по порядку, пока очередь не опустеет или send
+// не вернет ошибку. Возвращает количество отправленных пакетов. Сегмент читается
+// под блокировкой, отправляется без нее и затем укорачивается или удаляется.
+func (q *Queue) Replay(ctx context.Context, send SendFunc) (int, error) {
+	q.replayMu.Lock()
+	defer q.replayMu.Unlock()
+
+	now := time.Now()
+	q.mu.Lock()
+	q.trim(now)
+	q.mu.Unlock()
+
+	sent := 0
+	for {
+		q.mu.Lock()
+		if len(q.segments) == 0 {
+			q.mu.Unlock()
+			return sent, nil
+		}
+		seg := q.segments[0]
+		records, err := q.readSegment(seg.seq)
+		q.mu.Unlock()
+		if err != nil {
+			return sent, err
+		}
+
+		// done - количество обработанных пакетов сегмента, unsent - остаток пакета done
+		done := 0
+		var unsent []*zabbix.Metric
+		var sendErr error
+		for ; done < len(records); done++ {
+			if q.maxAge > 0 && now.Sub(records[done].Time) > q.maxAge {
+				q.logger.Warn("Dropping expired queued batch",
+					zap.Time("queued_at", records[done].Time),
+					zap.Int("values", len(records[done].Metrics)))
+				continue
+			}
+
+			sendErr = send(ctx, records[done].Metrics)
+			if sendErr != nil {
+				unsent = records[done].Metrics
+				var unsentErr *zabbix.UnsentValuesError
+				if errors.As(sendErr, &unsentErr) {
+					unsent = unsentErr.Metrics
+				}
+				break
+			}
+			sent++
+		}
+
+		if err := q.complete(seg, done, unsent); err != nil {
+			if sendErr == nil {
+				return sent, err
+			}
+			q.logger.Error("Failed to update queue segment", zap.Error(err))
+		}
+		if sendErr != nil {
+			return sent, sendErr
+		}
+	}
+}
+
+// complete убирает из головного сегмента done обработанных пакетов. Пакеты, добавленные
+// в сегмент во время отправки, сохраняются; unsent заменяет значения пакета done.
+// Сегмент, удаленный trim во время отправки, пропускается.
+func (q *Queue) complete(seg *segment, done int, unsent []*zabbix.Metric) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.segments) == 0 || q.segments[0] != seg {
+		return nil
+	}
+
+	records, err := q.readSegment(seg.seq)
+	if err != nil {
+		return err
+	}
+	records = records[min(done, len(records)):]
+	if len(records) > 0 && unsent != nil {
+		records[0].Metrics = unsent
+	}
+
+	if len(records) > 0 {
+		return q.rewriteSegment(seg, records)
+	}
+	if err := os.Remove(q.path(seg.seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove queue segment: %w", err)
+	}
+	q.segments = q.segments[1:]
+	return nil
+}
+
+// trim удаляет сегменты с устаревшими пакетами и самые старые сегменты сверх лимита размера
+func (q *Queue) trim(now time.Time) {
+	var total int64
+	for _, seg := range q.segments {
+		total += seg.size
+	}
+
+	for len(q.segments) > 0 {
+		seg := q.segments[0]
+		expired := q.maxAge > 0 && now.Sub(seg.newest) > q.maxAge
+		overflow := q.maxSize > 0 && total > q.maxSize
+		if !expired && !overflow {
+			return
+		}
+
+		if err := os.Remove(q.path(seg.seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			q.logger.Error("Failed to remove queue segment", zap.Uint64("segment", seg.seq), zap.Error(err))
+			return
+		}
+
+		q.logger.Warn("Dropped queued metrics",
+			zap.Bool("expired", expired),
+			zap.Int("batches", seg.batches),
+			zap.Int("values", seg.values),
+			zap.Time("oldest", seg.oldest))
+
+		total -= seg.size
+		q.segments = q.segments[1:]
+	}
+}
+
+// load читает сегменты, сохраненные предыдущим запуском
+func (q *Queue) load() error {
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*"+segmentExt))
+	if err != nil {
+		return fmt.Errorf("failed to list queue segments: %w", err)
+	}
+
+	for _, path := range paths {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), segmentExt), 10, 64)
+		if err != nil {
+			q.logger.Warn("Skipping unknown file in queue directory", zap.String("path", path))
+			continue
+		}
+
+		records, err := q.readSegment(seq)
+		if err != nil {
+			return err
+		}
+
+		// Перезаписываем сегмент, чтобы убрать недописанную запись перед новыми записями
+		seg := &segment{seq: seq}
+		if err := q.rewriteSegment(seg, records); err != nil {
+			return err
+		}
+		if seg.batches > 0 {
+			q.segments = append(q.segments, seg)
+		}
+		if seq >= q.nextSeq {
+			q.nextSeq = seq + 1
+		}
+	}
+
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i].seq < q.segments[j].seq
+	})
+
+	return nil
+}
+
+// readSegment читает пакеты сегмента. Поврежденные записи (например, недописанные
+// при аварийном завершении) пропускаются.
+func (q *Queue) readSegment(seq uint64) ([]record, error) {
+	file, err := os.Open(q.path(seq))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue segment: %w", err)
+	}
+	defer file.Close()
+
+	var records []record
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var rec record
+			if jsonErr := json.Unmarshal(line, &rec); jsonErr != nil {
+				q.logger.Warn("Skipping corrupted queue record",
+					zap.Uint64("segment", seq),
+					zap.Error(jsonErr))
+			} else {
+				records = append(records, rec)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queue segment: %w", err)
+		}
+	}
+}
+
+// rewriteSegment атомарно заменяет содержимое сегмента и пересчитывает его состояние
+func (q *Queue) rewriteSegment(seg *segment, records []record) error {
+	var data []byte
+	*seg = segment{seq: seg.seq}
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to marshal queued batch: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+
+		seg.batches++
+		seg.values += len(rec.Metrics)
+		if seg.oldest.IsZero() {
+			seg.oldest = rec.Time
+		}
+		seg.newest = rec.Time
+	}
+	seg.size = int64(len(data))
+
+	path := q.path(seg.seq)
+	if len(records) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove queue segment: %w", err)
+		}
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(q.dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary queue segment: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write queue segment: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync queue segment: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close queue segment: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace queue segment: %w", err)
+	}
+	return nil
+}
+
+// path возвращает путь к файлу сегмента
+func (q *Queue) path(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// appendFile дописывает данные в конец файла и сбрасывает их на диск
+func appendFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open queue segment: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write queue segment: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync queue segment: %w", err)
+	}
+	return file.Close()
+}
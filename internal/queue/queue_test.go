@@ -0,0 +1,107 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zabbix_mon/pkg/zabbix"
+
+	"go.uber.org/zap"
+)
+
+// batch создает пакет из одного значения с ключом key
+func batch(key string) []*zabbix.Metric {
+	return []*zabbix.Metric{zabbix.NewMetric("web-1", key, "1", 1700000000)}
+}
+
+func TestReplayDoesNotBlockPush(t *testing.T) {
+	q, err := New(t.TempDir(), 0, 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := q.Push(batch("a")); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	sending := make(chan struct{})
+	release := make(chan struct{})
+	var replayed []string
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.Replay(context.Background(), func(ctx context.Context, data []*zabbix.Metric) error {
+			if data[0].Key == "a" {
+				close(sending)
+				<-release
+			}
+			replayed = append(replayed, data[0].Key)
+			return nil
+		})
+		done <- err
+	}()
+
+	// Push во время отправки не ждет сеть и попадает в тот же сегмент
+	<-sending
+	pushed := make(chan error, 1)
+	go func() { pushed <- q.Push(batch("b")) }()
+	select {
+	case err := <-pushed:
+		if err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Push blocked while Replay was sending")
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(replayed) != 2 || replayed[0] != "a" || replayed[1] != "b" {
+		t.Errorf("replayed = %v, want [a b]", replayed)
+	}
+	if stats := q.Stats(); stats.Batches != 0 {
+		t.Errorf("queue stats after replay = %+v, want empty", stats)
+	}
+}
+
+func TestReplayKeepsUnsentValues(t *testing.T) {
+	dir := t.TempDir()
+	q, err := New(dir, 0, 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := q.Push(batch(key)); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+
+	failure := errors.New("connection refused")
+	sent, err := q.Replay(context.Background(), func(ctx context.Context, data []*zabbix.Metric) error {
+		if data[0].Key == "b" {
+			return &zabbix.UnsentValuesError{Metrics: batch("b2"), Err: failure}
+		}
+		return nil
+	})
+	if sent != 1 || !errors.Is(err, failure) {
+		t.Fatalf("Replay = %d, %v, want 1 sent and send error", sent, err)
+	}
+
+	// Оставшиеся пакеты переживают перезапуск
+	q, err = New(dir, 0, 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var replayed []string
+	if _, err := q.Replay(context.Background(), func(ctx context.Context, data []*zabbix.Metric) error {
+		replayed = append(replayed, data[0].Key)
+		return nil
+	}); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(replayed) != 2 || replayed[0] != "b2" || replayed[1] != "c" {
+		t.Errorf("replayed = %v, want [b2 c]", replayed)
+	}
+}
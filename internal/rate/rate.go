@@ -0,0 +1,192 @@
+package rate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"zabbix_mon/internal/collector"
+
+	"github.com/shirou/gopsutil/v3/host"
+	"go.uber.org/zap"
+)
+
+// staleAfter время, после которого сохраненное значение счетчика считается устаревшим
+const staleAfter = time.Hour
+
+// sample сохраненное значение счетчика
+type sample struct {
+	Value     uint64    `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// snapshot состояние калькулятора, сохраняемое на диск
+type snapshot struct {
+	BootTime uint64            `json:"boot_time"`
+	Samples  map[string]sample `json:"samples"`
+}
+
+// Calculator вычисляет скорости изменения накопительных счетчиков между циклами сбора
+type Calculator struct {
+	path   string
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	bootTime uint64
+	samples  map[string]sample
+}
+
+// New создает калькулятор скоростей и загружает сохраненное состояние.
+// Пустой path отключает сохранение состояния на диск.
+func New(path string, logger *zap.Logger) *Calculator {
+	c := &Calculator{
+		path:    path,
+		logger:  logger,
+		samples: make(map[string]sample),
+	}
+
+	bootTime, err := host.BootTime()
+	if err != nil {
+		logger.Warn("Failed to get boot time", zap.Error(err))
+	}
+	c.bootTime = bootTime
+
+	if err := c.load(); err != nil {
+		logger.Warn("Failed to load rate state", zap.String("path", path), zap.Error(err))
+	}
+
+	return c
+}
+
+// Process вычисляет скорости для всех счетчиков набора и добавляет их в набор
+func (c *Calculator) Process(set *collector.MetricSet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	computed := 0
+	for key, counter := range set.Counters {
+		prev, exists := c.samples[key]
+		c.samples[key] = sample{Value: counter.Value, Timestamp: set.Timestamp}
+
+		if !exists || set.Timestamp.Sub(prev.Timestamp) > staleAfter {
+			continue
+		}
+
+		rate, ok := calculate(prev, counter.Value, set.Timestamp)
+		if !ok {
+			c.logger.Debug("Counter reset detected",
+				zap.String("key", key),
+				zap.Uint64("previous", prev.Value),
+				zap.Uint64("current", counter.Value))
+			continue
+		}
+
+		set.Add(counter.RateKey, rate)
+		computed++
+	}
+
+	// Удаляем счетчики, которые давно не обновлялись (например, удаленные интерфейсы)
+	for key, s := range c.samples {
+		if set.Timestamp.Sub(s.Timestamp) > staleAfter {
+			delete(c.samples, key)
+		}
+	}
+
+	c.logger.Debug("Rates calculated",
+		zap.Int("counters", len(set.Counters)),
+		zap.Int("rates", computed))
+
+	if err := c.save(); err != nil {
+		c.logger.Warn("Failed to save rate state", zap.String("path", c.path), zap.Error(err))
+	}
+}
+
+// calculate вычисляет скорость изменения счетчика в секунду.
+// Возвращает false, если счетчик был сброшен и скорость вычислить нельзя.
+func calculate(prev sample, value uint64, timestamp time.Time) (float64, bool) {
+	elapsed := timestamp.Sub(prev.Timestamp).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+
+	// 64-битные счетчики не переполняются на практике, уменьшение означает сброс
+	// (перезагрузка, пересоздание интерфейса), значение пропускается
+	if value < prev.Value {
+		return 0, false
+	}
+
+	return float64(value-prev.Value) / elapsed, true
+}
+
+// load загружает сохраненное состояние с диска
+func (c *Calculator) load() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state snapshot
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	// После перезагрузки хоста все счетчики начинаются заново
+	if state.BootTime != c.bootTime {
+		c.logger.Info("Host was rebooted, discarding saved rate state")
+		return nil
+	}
+
+	if state.Samples != nil {
+		c.samples = state.Samples
+	}
+
+	c.logger.Info("Loaded rate state", zap.Int("counters", len(c.samples)))
+	return nil
+}
+
+// save атомарно сохраняет состояние на диск
+func (c *Calculator) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(snapshot{BootTime: c.bootTime, Samples: c.samples})
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close state file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	return nil
+}
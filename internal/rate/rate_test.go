@@ -0,0 +1,79 @@
+package rate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"zabbix_mon/internal/collector"
+
+	"go.uber.org/zap"
+)
+
+func TestCalculate(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		prev    uint64
+		value   uint64
+		elapsed time.Duration
+		want    float64
+		wantOK  bool
+	}{
+		{name: "increase", prev: 1000, value: 3000, elapsed: 10 * time.Second, want: 200, wantOK: true},
+		{name: "unchanged", prev: 1000, value: 1000, elapsed: 10 * time.Second, want: 0, wantOK: true},
+		{name: "reset", prev: 1 << 40, value: 5, elapsed: 10 * time.Second},
+		{name: "no elapsed time", prev: 1000, value: 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := calculate(sample{Value: tt.prev, Timestamp: start}, tt.value, start.Add(tt.elapsed))
+			if ok != tt.wantOK {
+				t.Fatalf("calculate ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("calculate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "state.json")
+	calc := New(path, zap.NewNop())
+	start := time.Unix(1700000000, 0)
+
+	cycle := func(offset time.Duration, value uint64) *collector.MetricSet {
+		set := collector.NewMetricSet(start.Add(offset))
+		set.AddCounter("net.if.in[eth0]", "net.if.in.rate[eth0]", value)
+		calc.Process(set)
+		return set
+	}
+
+	// Первое значение только запоминается
+	if _, ok := cycle(0, 1000).Get("net.if.in.rate[eth0]"); ok {
+		t.Errorf("rate published for the first sample")
+	}
+
+	if rate, ok := cycle(10*time.Second, 2000).Get("net.if.in.rate[eth0]"); !ok || rate != 100.0 {
+		t.Errorf("rate = %v (%v), want 100", rate, ok)
+	}
+
+	// Сброс пропускает одно значение, следующее вычисляется от нового базового
+	if _, ok := cycle(20*time.Second, 50).Get("net.if.in.rate[eth0]"); ok {
+		t.Errorf("rate published after counter reset")
+	}
+
+	// Состояние переживает перезапуск
+	calc = New(path, zap.NewNop())
+	if rate, ok := cycle(30*time.Second, 550).Get("net.if.in.rate[eth0]"); !ok || rate != 50.0 {
+		t.Errorf("rate after restart = %v (%v), want 50", rate, ok)
+	}
+
+	// Устаревшее значение не используется
+	if _, ok := cycle(30*time.Second+2*staleAfter, 1000).Get("net.if.in.rate[eth0]"); ok {
+		t.Errorf("rate published for a stale sample")
+	}
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"zabbix_mon/internal/collector"
+	"zabbix_mon/pkg/zabbix"
+
+	"go.uber.org/zap"
+)
+
+// activeBufferSize максимальное количество неотправленных значений активных проверок
+const activeBufferSize = 1000
+
+// activeItem активная проверка с расписанием
+type activeItem struct {
+	check       zabbix.ActiveCheck
+	delay       time.Duration
+	next        time.Time
+	unsupported bool // причина отсутствия значения уже отправлена
+	known       bool // ключ публикуется сборщиком, но значения может еще не быть
+}
+
+// activeState состояние активного режима агента
+type activeState struct {
+	session     *zabbix.ActiveSession
+	items       map[string]*activeItem
+	refreshedAt time.Time
+
+	// Значения, не отправленные из-за недоступности сервера.
+	// Сервер отбрасывает повторы по номеру значения в сессии.
+	pending []*zabbix.AgentValue
+}
+
+// newActiveState создает состояние активного режима
+func newActiveState(host string) (*activeState, error) {
+	session, err := zabbix.NewActiveSession(host)
+	if err != nil {
+		return nil, err
+	}
+	return &activeState{
+		session: session,
+		items:   make(map[string]*activeItem),
+	}, nil
+}
+
+// refreshActiveChecks обновляет список активных проверок, если он устарел
+func (s *Scheduler) refreshActiveChecks(ctx context.Context) error {
+	if !s.active.refreshedAt.IsZero() && time.Since(s.active.refreshedAt) < s.config.ActiveRefresh {
+		return nil
+	}
+
+	checks, err := s.zabbix.GetActiveChecks(ctx, s.config.ZabbixHost, s.config.HostMetadata)
+	if err != nil {
+		// Продолжаем работать с ранее полученным списком
+		if !s.active.refreshedAt.IsZero() {
+			s.logger.Warn("Failed to refresh active checks, using previous list", zap.Error(err))
+			return nil
+		}
+		return fmt.Errorf("failed to get active checks: %w", err)
+	}
+	s.active.refreshedAt = time.Now()
+
+	items := make(map[string]*activeItem, len(checks))
+	for _, check := range checks {
+		delay, err := zabbix.ParseDelay(check.Delay)
+		if err != nil || delay <= 0 {
+			s.logger.Warn("Skipping active check with unsupported interval",
+				zap.String("key", check.Key),
+				zap.String("delay", check.Delay))
+			continue
+		}
+
+		// Сохраняем расписание проверок, которые не изменились
+		item := &activeItem{check: check, delay: delay, known: s.zabbix.IsKnownKey(check.Key)}
+		if prev, exists := s.active.items[check.Key]; exists && prev.delay == delay {
+			item.next = prev.next
+			item.unsupported = prev.unsupported
+		}
+		items[check.Key] = item
+	}
+	s.active.items = items
+
+	s.logger.Debug("Active checks refreshed", zap.Int("checks", len(items)))
+	return nil
+}
+
+// reportActiveChecks отправляет значения активных проверок, срок которых наступил.
+// Проверки выполняются не чаще интервала сбора.
+func (s *Scheduler) reportActiveChecks(ctx context.Context, metrics *collector.MetricSet) error {
+	if err := s.refreshActiveChecks(ctx); err != nil {
+		return err
+	}
+
+	values := s.active.pending
+	for key, item := range s.active.items {
+		if metrics.Timestamp.Before(item.next) {
+			continue
+		}
+		item.next = metrics.Timestamp.Add(item.delay)
+
+		value, ok := metrics.Get(key)
+		if ok {
+			item.unsupported = false
+			values = append(values, s.active.session.Value(key, fmt.Sprintf("%v", value), zabbix.StateNormal, metrics.Timestamp))
+			continue
+		}
+
+		// Скорости и значения ядер появляются после первой разницы, значение пропускается
+		if item.known {
+			continue
+		}
+
+		// Сообщаем серверу о неподдерживаемом ключе один раз
+		if !item.unsupported {
+			item.unsupported = true
+			values = append(values, s.active.session.Value(key, "Unsupported item key.", zabbix.StateNotSupported, metrics.Timestamp))
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	resp, err := s.zabbix.SendAgentData(ctx, s.active.session, values)
+	if err != nil {
+		if len(values) > activeBufferSize {
+			s.logger.Warn("Active check buffer is full, dropping oldest values",
+				zap.Int("dropped", len(values)-activeBufferSize))
+			values = values[len(values)-activeBufferSize:]
+		}
+		s.active.pending = values
+		return err
+	}
+	s.active.pending = nil
+
+	s.logger.Debug("Zabbix accepted active check values",
+		zap.Int("processed", resp.Processed),
+		zap.Int("failed", resp.Failed),
+		zap.Int("total", resp.Total))
+	if resp.Failed > 0 {
+		s.failedValues += resp.Failed
+		s.partialFailures++
+		s.logger.Warn("Zabbix rejected some active check values",
+			zap.Int("failed", resp.Failed),
+			zap.Int("total", resp.Total))
+	}
+
+	return nil
+}
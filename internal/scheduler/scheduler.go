@@ -2,44 +2,190 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"zabbix_mon/internal/collector"
 	"zabbix_mon/internal/config"
+	"zabbix_mon/internal/passive"
+	"zabbix_mon/internal/queue"
+	"zabbix_mon/internal/rate"
 	"zabbix_mon/pkg/profiler"
 	"zabbix_mon/pkg/zabbix"
 
 	"go.uber.org/zap"
 )
 
+// bisectInterval минимальный интервал между поисками отклоненных значений делением пакета
+const bisectInterval = 10 * time.Minute
+
 // Scheduler отвечает за планирование и координацию работы
 type Scheduler struct {
 	config    *config.Config
 	collector *collector.Collector
+	rates     *rate.Calculator
+	queue     *queue.Queue    // nil, если очередь отключена
+	active    *activeState    // nil в режиме trapper
+	passive   *passive.Server // nil, если пассивные проверки отключены
 	zabbix    *zabbix.Client
 	logger    *zap.Logger
 	profiler  *profiler.Profiler
 
+	// Последний собранный набор метрик для пассивных проверок
+	lastMetrics atomic.Pointer[collector.MetricSet]
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	// Статистика для профилирования
 	cycleCount int
+
+	// Статистика отклоненных сервером значений
+	failedValues    int
+	partialFailures int
+
+	// Время последнего поиска отклоненных значений делением пакета
+	lastBisect time.Time
 }
 
 // New создает новый планировщик
-func New(cfg *config.Config, logger *zap.Logger) *Scheduler {
+func New(cfg *config.Config, logger *zap.Logger) (*Scheduler, error) {
+	metricsCollector, err := collector.New(collector.Config{
+		Sources:         cfg.Sources,
+		DisabledSources: cfg.DisabledSources,
+		CPUPerCore:      cfg.CPUPerCore,
+		FSTypes:         cfg.FSTypes,
+		FSExcludeTypes:  cfg.FSExcludeTypes,
+		FSMountInclude:  cfg.FSMountInclude,
+		FSMountExclude:  cfg.FSMountExclude,
+		NetIfInclude:    cfg.NetIfInclude,
+		NetIfExclude:    cfg.NetIfExclude,
+		DevInclude:      cfg.DevInclude,
+		DevExclude:      cfg.DevExclude,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collector: %w", err)
+	}
+
+	tlsConfig, err := zabbix.NewTLSConfig(zabbix.TLSOptions{
+		Connect:           cfg.TLSConnect,
+		CAFile:            cfg.TLSCAFile,
+		CertFile:          cfg.TLSCertFile,
+		KeyFile:           cfg.TLSKeyFile,
+		ServerCertIssuer:  cfg.TLSServerCertIssuer,
+		ServerCertSubject: cfg.TLSServerCertSubject,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+
+	zabbixClient := zabbix.NewClient(cfg.ZabbixURL, cfg.ZabbixUser, cfg.ZabbixPassword, cfg.HTTPTimeout, logger)
+	if cfg.ZabbixAPIToken != "" {
+		zabbixClient.SetAPIToken(cfg.ZabbixAPIToken)
+	}
+	// Очередь неотправленных значений
+	var metricQueue *queue.Queue
+	keys := metricsCollector.Keys()
+	if cfg.QueueDir != "" {
+		metricQueue, err = queue.New(cfg.QueueDir, cfg.QueueMaxSize, cfg.QueueMaxAge, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open metric queue: %w", err)
+		}
+		keys = append(keys, queue.Keys()...)
+	}
+
+	zabbixClient.SetEnabledKeys(keys)
+	zabbixClient.SetBatchConfig(zabbix.BatchConfig{
+		Size:        cfg.BatchSize,
+		MaxBytes:    cfg.MaxPacketSize,
+		Parallelism: cfg.SenderParallelism,
+	})
+	zabbixClient.SetSenderConfig(zabbix.SenderConfig{
+		Servers: cfg.ZabbixServers,
+		Policy:  cfg.SenderPolicy,
+
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+
+		Compress: cfg.SenderCompress,
+		TLS:      tlsConfig,
+
+		DialTimeout:  cfg.SenderDialTimeout,
+		ReadTimeout:  cfg.SenderReadTimeout,
+		WriteTimeout: cfg.SenderWriteTimeout,
+		PoolSize:     cfg.SenderPoolSize,
+	})
+
+	hostTags, err := zabbix.ParseHostTags(cfg.HostTags)
+	if err != nil {
+		return nil, err
+	}
+	zabbixClient.SetHostConfig(zabbix.HostConfig{
+		AutoRegister: cfg.AutoRegister,
+		Groups:       cfg.HostGroups,
+		Templates:    cfg.HostTemplates,
+		VisibleName:  cfg.HostVisibleName,
+		Tags:         hostTags,
+		Interface:    cfg.HostInterface,
+	})
+
+	if cfg.CreateTriggers {
+		triggers, err := zabbix.LoadTriggers(cfg.TriggersFile)
+		if err != nil {
+			return nil, err
+		}
+		zabbixClient.SetTriggers(triggers)
+	}
+
+	// В режиме проверки план изменений выводится и без флага reconcile-items
+	zabbixClient.SetReconcileConfig(zabbix.ReconcileConfig{
+		Enabled: cfg.ReconcileItems || cfg.ReconcileDryRun,
+		Prune:   cfg.ReconcilePrune,
+		DryRun:  cfg.ReconcileDryRun,
+
+		MigrateLegacy: cfg.MigrateLegacyItems,
+	})
+
+	// Состояние активного режима агента
+	var active *activeState
+	if cfg.Mode == config.ModeActive {
+		active, err = newActiveState(cfg.ZabbixHost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create active agent session: %w", err)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Scheduler{
+	s := &Scheduler{
 		config:    cfg,
-		collector: collector.New(logger),
-		zabbix:    zabbix.NewClient(cfg.ZabbixURL, cfg.ZabbixUser, cfg.ZabbixPassword, cfg.HTTPTimeout, logger),
+		collector: metricsCollector,
+		rates:     rate.New(cfg.StateFile, logger),
+		queue:     metricQueue,
+		active:    active,
+		zabbix:    zabbixClient,
 		logger:    logger,
 		ctx:       ctx,
 		cancel:    cancel,
 	}
+
+	// Пассивные проверки отвечают значениями последнего цикла сбора
+	if cfg.PassiveListen != "" {
+		s.passive, err = passive.New(passive.Config{
+			ListenAddr:     cfg.PassiveListen,
+			AllowedServers: cfg.AllowedServers,
+			Timeout:        cfg.PassiveTimeout,
+			HostName:       cfg.ZabbixHost,
+		}, s.lastMetrics.Load, logger)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create passive checks listener: %w", err)
+		}
+	}
+
+	return s, nil
 }
 
 // SetProfiler устанавливает профайлер для периодического логирования статистик
@@ -51,11 +197,28 @@ func (s *Scheduler) SetProfiler(p *profiler.Profiler) {
 func (s *Scheduler) Start() error {
 	s.logger.Info("Starting scheduler",
 		zap.Duration("interval", s.config.Interval),
-		zap.String("zabbix_host", s.config.ZabbixHost))
+		zap.String("zabbix_host", s.config.ZabbixHost),
+		zap.String("mode", s.config.Mode))
+
+	switch {
+	case s.config.Mode == config.ModePassive:
+		// Значения только запрашиваются сервером, отправка не нужна
+	case s.active != nil:
+		// В активном режиме элементы данных настраиваются в интерфейсе Zabbix, API не нужен
+		if err := s.zabbix.InitSenders(); err != nil {
+			return fmt.Errorf("failed to initialize Zabbix sender: %w", err)
+		}
+	default:
+		// Инициализируем Zabbix клиент
+		if err := s.zabbix.Initialize(s.ctx, s.config.ZabbixHost); err != nil {
+			return fmt.Errorf("failed to initialize Zabbix client: %w", err)
+		}
+	}
 
-	// Инициализируем Zabbix клиент
-	if err := s.zabbix.Initialize(s.ctx, s.config.ZabbixHost); err != nil {
-		return fmt.Errorf("failed to initialize Zabbix client: %w", err)
+	if s.passive != nil {
+		if err := s.passive.Start(s.ctx); err != nil {
+			return err
+		}
 	}
 
 	// Запускаем основной цикл мониторинга
@@ -89,6 +252,10 @@ func (s *Scheduler) monitoringLoop() {
 		case <-ticker.C:
 			s.collectAndSend()
 		case <-s.ctx.Done():
+			if s.passive != nil {
+				s.passive.Wait()
+			}
+			s.zabbix.Close()
 			s.logger.Info("Monitoring loop stopped")
 			return
 		}
@@ -111,11 +278,29 @@ func (s *Scheduler) collectAndSend() {
 		return
 	}
 
+	// Вычисляем скорости изменения счетчиков
+	s.rates.Process(metrics)
+
+	// Добавляем метрики самомониторинга очереди
+	if s.queue != nil {
+		s.queue.Collect(metrics)
+	}
+	s.lastMetrics.Store(metrics)
+
 	collectDuration := time.Since(start)
 
 	// Отправляем метрики в Zabbix
 	sendStart := time.Now()
-	if err := s.sendMetricsWithRetry(ctx, metrics); err != nil {
+	if s.config.Mode == config.ModePassive {
+		s.logger.Debug("Metrics collected for passive checks", zap.Int("cycle", s.cycleCount))
+		return
+	}
+	if s.active != nil {
+		if err := s.reportActiveChecks(ctx, metrics); err != nil {
+			s.logger.Error("Failed to report active checks", zap.Error(err))
+			return
+		}
+	} else if err := s.deliverMetrics(ctx, metrics); err != nil {
 		s.logger.Error("Failed to send metrics after retries", zap.Error(err))
 		return
 	}
@@ -136,10 +321,84 @@ func (s *Scheduler) collectAndSend() {
 	}
 }
 
-// sendMetricsWithRetry отправляет метрики с повторными попытками
-func (s *Scheduler) sendMetricsWithRetry(ctx context.Context, metrics *collector.MetricSet) error {
+// deliverMetrics отправляет метрики в Zabbix. Если сервер недоступен и очередь включена,
+// недоставленные значения сохраняются в очередь и отправляются в следующих циклах.
+func (s *Scheduler) deliverMetrics(ctx context.Context, metrics *collector.MetricSet) error {
+	data, err := s.zabbix.PrepareMetrics(ctx, metrics)
+	if err != nil {
+		s.reconnectOnAuthError(ctx, err)
+	}
+	if s.queue == nil {
+		_, err := s.sendMetricsWithRetry(ctx, data)
+		return err
+	}
+
+	// Сначала отправляем накопленные значения, чтобы сохранить порядок
+	if s.queue.Stats().Batches > 0 {
+		if err := s.replayQueue(ctx); err != nil {
+			return s.enqueue(data, err)
+		}
+	}
+
+	unsent, err := s.sendMetricsWithRetry(ctx, data)
+	if len(unsent) > 0 {
+		return s.enqueue(unsent, err)
+	}
+	return err
+}
+
+// replayQueue отправляет накопленные в очереди значения
+func (s *Scheduler) replayQueue(ctx context.Context) error {
+	sent, err := s.queue.Replay(ctx, func(ctx context.Context, data []*zabbix.Metric) error {
+		_, err := s.zabbix.SendData(ctx, data)
+
+		// Отклоненные значения не имеет смысла хранить в очереди
+		var failedErr *zabbix.FailedValuesError
+		if errors.As(err, &failedErr) {
+			s.failedValues += failedErr.Response.Failed
+			s.partialFailures++
+			s.logger.Warn("Zabbix rejected some queued values",
+				zap.Int("failed", failedErr.Response.Failed),
+				zap.Int("total", failedErr.Response.Total))
+			return nil
+		}
+		return err
+	})
+
+	if sent > 0 {
+		stats := s.queue.Stats()
+		s.logger.Info("Sent queued metrics",
+			zap.Int("batches", sent),
+			zap.Int("remaining_batches", stats.Batches))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send queued metrics: %w", err)
+	}
+	return nil
+}
+
+// enqueue сохраняет недоставленные значения в очередь
+func (s *Scheduler) enqueue(data []*zabbix.Metric, sendErr error) error {
+	if err := s.queue.Push(data); err != nil {
+		s.logger.Error("Failed to queue metrics", zap.Error(err))
+		return sendErr
+	}
+
+	stats := s.queue.Stats()
+	s.logger.Warn("Metrics queued for later delivery",
+		zap.Int("values", len(data)),
+		zap.Int("queued_batches", stats.Batches),
+		zap.Duration("queue_age", stats.Age),
+		zap.Error(sendErr))
+	return fmt.Errorf("metrics queued for later delivery: %w", sendErr)
+}
+
+// sendMetricsWithRetry отправляет значения с повторными попытками.
+// При ошибке доставки возвращает недоставленные значения.
+func (s *Scheduler) sendMetricsWithRetry(ctx context.Context, data []*zabbix.Metric) ([]*zabbix.Metric, error) {
 	var lastErr error
 	backoff := s.config.RetryBackoffBase
+	resent := false // пакет с отклоненными значениями уже отправлен повторно
 
 	for attempt := 0; attempt < s.config.MaxRetries; attempt++ {
 		if attempt > 0 {
@@ -152,55 +411,161 @@ func (s *Scheduler) sendMetricsWithRetry(ctx context.Context, metrics *collector
 			select {
 			case <-time.After(backoff):
 			case <-ctx.Done():
-				return ctx.Err()
+				return data, ctx.Err()
 			}
 
 			backoff *= 2 // экспоненциальное увеличение
 		}
 
-		err := s.zabbix.SendMetrics(ctx, metrics)
+		resp, err := s.zabbix.SendData(ctx, data)
 		if err == nil {
 			if attempt > 0 {
 				s.logger.Info("Metrics sent successfully after retry",
 					zap.Int("attempts", attempt+1))
 			}
-			return nil
+			s.logger.Debug("Zabbix accepted metrics",
+				zap.Int("processed", resp.Processed),
+				zap.Int("total", resp.Total))
+			return nil, nil
 		}
 
 		lastErr = err
+
+		// Сервер принял только часть значений
+		var failedErr *zabbix.FailedValuesError
+		if errors.As(err, &failedErr) {
+			s.failedValues += failedErr.Response.Failed
+			s.partialFailures++
+			s.logger.Warn("Zabbix rejected some values",
+				zap.Int("processed", failedErr.Response.Processed),
+				zap.Int("failed", failedErr.Response.Failed),
+				zap.Int("total", failedErr.Response.Total),
+				zap.Int("attempt", attempt+1))
+
+			// Отклоненные значения часто относятся к отсутствующим элементам, создаем только их
+			if rejected := s.findRejected(ctx, failedErr); len(rejected) > 0 {
+				data = rejected
+				if attempt < s.config.MaxRetries-1 {
+					if _, createErr := s.zabbix.CreateItems(ctx, metricKeys(rejected)); createErr != nil {
+						s.logger.Error("Failed to create items for rejected values", zap.Error(createErr))
+					}
+				}
+				continue
+			}
+
+			// Без списка отклоненных значений создаем отсутствующие элементы всего пакета
+			// и повторяем его один раз, только если элементы созданы: принятые значения продублируются
+			if !resent && attempt < s.config.MaxRetries-1 {
+				resent = true
+				created, createErr := s.zabbix.CreateItems(ctx, metricKeys(failedErr.Metrics))
+				if createErr != nil {
+					s.logger.Error("Failed to create items for rejected values", zap.Error(createErr))
+				}
+				if created > 0 {
+					s.logger.Info("Created missing items, resending rejected batch",
+						zap.Int("created", created),
+						zap.Int("values", len(failedErr.Metrics)))
+					data = failedErr.Metrics
+					continue
+				}
+			}
+
+			s.logger.Warn("Rejected values dropped",
+				zap.Int("failed", failedErr.Response.Failed),
+				zap.Int("values", len(failedErr.Metrics)),
+				zap.Bool("sender_bisect", s.config.SenderBisect))
+			return nil, fmt.Errorf("metrics partially rejected: %w", err)
+		}
+
 		s.logger.Warn("Failed to send metrics",
 			zap.Error(err),
 			zap.Int("attempt", attempt+1))
 
+		// Доставленные пакеты не отправляем повторно
+		var unsentErr *zabbix.UnsentValuesError
+		if errors.As(err, &unsentErr) {
+			data = unsentErr.Metrics
+		}
+
 		// Если это ошибка аутентификации, пытаемся переподключиться
-		if s.isAuthError(err) && attempt < s.config.MaxRetries-1 {
-			s.logger.Info("Authentication error detected, re-initializing Zabbix client")
-			if reInitErr := s.zabbix.Initialize(ctx, s.config.ZabbixHost); reInitErr != nil {
-				s.logger.Error("Failed to re-initialize Zabbix client", zap.Error(reInitErr))
-			}
+		if attempt < s.config.MaxRetries-1 {
+			s.reconnectOnAuthError(ctx, err)
 		}
 	}
 
-	return fmt.Errorf("failed to send metrics after %d attempts: %w", s.config.MaxRetries, lastErr)
+	// Отклоненные сервером значения не считаются недоставленными
+	var failedErr *zabbix.FailedValuesError
+	if errors.As(lastErr, &failedErr) {
+		data = nil
+	}
+
+	return data, fmt.Errorf("failed to send metrics after %d attempts: %w", s.config.MaxRetries, lastErr)
 }
 
-// isAuthError проверяет, является ли ошибка ошибкой аутентификации
-func (s *Scheduler) isAuthError(err error) bool {
-	errStr := err.Error()
-	// Проверяем на типичные ошибки аутентификации Zabbix
-	return err != nil && (fmt.Sprintf("%v", err) == "Session terminated, re-login, please." ||
-		fmt.Sprintf("%v", err) == "Not authorized" ||
-		fmt.Sprintf("%v", err) == "Invalid params" &&
-			(fmt.Sprintf("%v", errStr) == "Session terminated" ||
-				fmt.Sprintf("%v", errStr) == "invalid auth token"))
+// findRejected определяет отклоненные сервером значения, если это разрешено конфигурацией.
+// Деление пакета дублирует принятые значения, поэтому выполняется не чаще раза в bisectInterval.
+func (s *Scheduler) findRejected(ctx context.Context, failedErr *zabbix.FailedValuesError) []*zabbix.Metric {
+	if !s.config.SenderBisect {
+		return nil
+	}
+	if since := time.Since(s.lastBisect); since < bisectInterval {
+		s.logger.Debug("Skipping search for rejected values",
+			zap.Duration("next_in", bisectInterval-since))
+		return nil
+	}
+	s.lastBisect = time.Now()
+
+	rejected, err := s.zabbix.FindRejected(ctx, failedErr)
+	if err != nil {
+		s.logger.Warn("Failed to find rejected values", zap.Error(err))
+	}
+
+	s.logger.Warn("Values rejected by Zabbix", zap.Strings("keys", metricKeys(rejected)))
+
+	return rejected
+}
+
+// metricKeys возвращает ключи значений
+func metricKeys(data []*zabbix.Metric) []string {
+	keys := make([]string, 0, len(data))
+	for _, metric := range data {
+		keys = append(keys, metric.Key)
+	}
+	return keys
+}
+
+// isAuthError проверяет, является ли ошибка ошибкой аутентификации Zabbix API
+func isAuthError(err error) bool {
+	var apiErr *zabbix.JSONRPCError
+	return errors.As(err, &apiErr) && apiErr.SessionExpired()
+}
+
+// reconnectOnAuthError повторно инициализирует клиент, если сессия Zabbix API истекла
+func (s *Scheduler) reconnectOnAuthError(ctx context.Context, err error) {
+	if !isAuthError(err) {
+		return
+	}
+
+	s.logger.Info("Authentication error detected, re-initializing Zabbix client", zap.Error(err))
+	if reInitErr := s.zabbix.Initialize(ctx, s.config.ZabbixHost); reInitErr != nil {
+		s.logger.Error("Failed to re-initialize Zabbix client", zap.Error(reInitErr))
+	}
 }
 
 // GetStats возвращает статистику работы
 func (s *Scheduler) GetStats() map[string]interface{} {
-	return map[string]interface{}{
-		"interval":    s.config.Interval.String(),
-		"zabbix_url":  s.config.ZabbixURL,
-		"zabbix_host": s.config.ZabbixHost,
-		"running":     s.ctx.Err() == nil,
+	stats := map[string]interface{}{
+		"interval":         s.config.Interval.String(),
+		"zabbix_url":       s.config.ZabbixURL,
+		"zabbix_host":      s.config.ZabbixHost,
+		"mode":             s.config.Mode,
+		"running":          s.ctx.Err() == nil,
+		"failed_values":    s.failedValues,
+		"partial_failures": s.partialFailures,
+		"targets":          s.zabbix.TargetStatus(),
+	}
+	if s.queue != nil {
+		stats["queue"] = s.queue.Stats()
 	}
+	return stats
 }
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"zabbix_mon/internal/config"
+	"zabbix_mon/pkg/zabbix"
+
+	"go.uber.org/zap"
+)
+
+// zabbixStub заглушка Zabbix API и trapper для хоста web-1
+type zabbixStub struct {
+	mu      sync.Mutex
+	items   []zabbix.Item // элементы хоста, возвращаемые item.get
+	created []string      // ключи элементов, созданных через item.create
+	packets int           // число полученных пакетов sender data
+	failed  []int         // число отклоненных значений по порядку пакетов
+}
+
+func (s *zabbixStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		ID     int             `json:"id"`
+	}
+	json.NewDecoder(r.Body).Decode(&request)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result interface{}
+	switch request.Method {
+	case "apiinfo.version":
+		result = "6.0.0"
+	case "user.login":
+		result = "token"
+	case "host.get":
+		result = []zabbix.Host{{HostID: "10084", Host: "web-1", Name: "web-1", Status: "0"}}
+	case "item.get":
+		result = s.items
+	case "discoveryrule.get":
+		result = []zabbix.DiscoveryRule{}
+	case "item.create":
+		var params []zabbix.ItemCreateParams
+		json.Unmarshal(request.Params, &params)
+		ids := make([]string, len(params))
+		for i, item := range params {
+			s.created = append(s.created, item.Key)
+			ids[i] = strconv.Itoa(100 + len(s.created))
+		}
+		result = map[string][]string{"itemids": ids}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": result})
+}
+
+// reply отвечает на пакет sender data, отклоняя заданное для пакета число значений
+func (s *zabbixStub) reply(request []byte) []byte {
+	var packet zabbix.Packet
+	json.Unmarshal(request, &packet)
+
+	s.mu.Lock()
+	failed := 0
+	if s.packets < len(s.failed) {
+		failed = s.failed[s.packets]
+	}
+	s.packets++
+	s.mu.Unlock()
+
+	info := fmt.Sprintf("processed: %d; failed: %d; total: %d; seconds spent: 0.000055",
+		len(packet.Data)-failed, failed, len(packet.Data))
+	data, _ := json.Marshal(map[string]string{"response": "success", "info": info})
+	return data
+}
+
+// serveTrapper обслуживает соединения trapper на слушателе ln
+func (s *zabbixStub) serveTrapper(t *testing.T, ln net.Listener) {
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					request, err := zabbix.ReadFrame(conn)
+					if err != nil {
+						return
+					}
+					frame, err := zabbix.EncodeFrame(s.reply(request), false)
+					if err != nil {
+						return
+					}
+					if _, err := conn.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+}
+
+// startScheduler запускает заглушку Zabbix и возвращает планировщик с инициализированным клиентом
+func startScheduler(t *testing.T, stub *zabbixStub) *Scheduler {
+	t.Helper()
+
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	stub.serveTrapper(t, ln)
+
+	client := zabbix.NewClient(server.URL, "Admin", "zabbix", time.Second, zap.NewNop())
+	client.SetEnabledKeys([]string{"vm.memory.util", "system.cpu.num"})
+	client.SetSenderConfig(zabbix.SenderConfig{
+		Servers:      []string{ln.Addr().String()},
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	})
+	if err := client.Initialize(context.Background(), "web-1"); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	return &Scheduler{
+		config: &config.Config{
+			ZabbixHost:       "web-1",
+			MaxRetries:       3,
+			RetryBackoffBase: time.Millisecond,
+		},
+		zabbix: client,
+		logger: zap.NewNop(),
+	}
+}
+
+func TestSendMetricsWithRetryRejectedWithoutBisect(t *testing.T) {
+	data := []*zabbix.Metric{
+		zabbix.NewMetric("web-1", "vm.memory.util", "42.5", 1700000000),
+		zabbix.NewMetric("web-1", "system.cpu.num", "4", 1700000000),
+	}
+
+	t.Run("missing item created and batch resent once", func(t *testing.T) {
+		stub := &zabbixStub{
+			items: []zabbix.Item{
+				{ItemID: "1", Key: "vm.memory.util", Flags: "0"},
+				{ItemID: "2", Key: "system.cpu.num", Flags: "0"},
+			},
+			failed: []int{1},
+		}
+		s := startScheduler(t, stub)
+
+		// Элемент удален оператором после запуска
+		stub.mu.Lock()
+		stub.items = stub.items[1:]
+		stub.mu.Unlock()
+
+		unsent, err := s.sendMetricsWithRetry(context.Background(), data)
+		if err != nil || len(unsent) != 0 {
+			t.Fatalf("sendMetricsWithRetry = %d unsent, %v, want delivered", len(unsent), err)
+		}
+		if len(stub.created) != 1 || stub.created[0] != "vm.memory.util" {
+			t.Errorf("created items = %v, want [vm.memory.util]", stub.created)
+		}
+		if stub.packets != 2 {
+			t.Errorf("trapper got %d packets, want 2", stub.packets)
+		}
+	})
+
+	t.Run("rejected values dropped when nothing to create", func(t *testing.T) {
+		stub := &zabbixStub{
+			items: []zabbix.Item{
+				{ItemID: "1", Key: "vm.memory.util", Flags: "0"},
+				{ItemID: "2", Key: "system.cpu.num", Flags: "0"},
+			},
+			failed: []int{1, 1, 1},
+		}
+		s := startScheduler(t, stub)
+
+		unsent, err := s.sendMetricsWithRetry(context.Background(), data)
+		var failedErr *zabbix.FailedValuesError
+		if !errors.As(err, &failedErr) || len(unsent) != 0 {
+			t.Fatalf("sendMetricsWithRetry = %d unsent, %v, want rejected values dropped", len(unsent), err)
+		}
+		if len(stub.created) != 0 {
+			t.Errorf("created items = %v, want none", stub.created)
+		}
+		// Без созданных элементов повторная отправка только задублирует принятые значения
+		if stub.packets != 1 {
+			t.Errorf("trapper got %d packets, want 1", stub.packets)
+		}
+		if s.failedValues != 1 || s.partialFailures != 1 {
+			t.Errorf("stats = %d failed values, %d partial failures, want 1 and 1", s.failedValues, s.partialFailures)
+		}
+	})
+}
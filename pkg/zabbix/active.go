@@ -0,0 +1,218 @@
+package zabbix
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AgentVersion версия протокола, сообщаемая серверу в активном режиме
+const AgentVersion = "6.0"
+
+// ActiveChecksRequest запрос списка активных проверок хоста
+type ActiveChecksRequest struct {
+	Request      string `json:"request"`
+	Host         string `json:"host"`
+	HostMetadata string `json:"host_metadata,omitempty"`
+	Version      string `json:"version"`
+}
+
+// ActiveCheck элемент данных типа "Zabbix agent (active)"
+type ActiveCheck struct {
+	Key         string `json:"key"`
+	KeyOrig     string `json:"key_orig,omitempty"`
+	ItemID      uint64 `json:"itemid,omitempty"`
+	Delay       string `json:"delay"`
+	LastLogSize uint64 `json:"lastlogsize"`
+	MTime       int64  `json:"mtime"`
+}
+
+// ActiveChecksResponse ответ сервера на запрос активных проверок
+type ActiveChecksResponse struct {
+	Response string        `json:"response"`
+	Info     string        `json:"info,omitempty"`
+	Data     []ActiveCheck `json:"data"`
+}
+
+// Состояния значения в активном режиме
+const (
+	StateNormal       = 0
+	StateNotSupported = 1
+)
+
+// AgentValue значение активной проверки
+type AgentValue struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	State int    `json:"state,omitempty"` // StateNotSupported - value содержит причину
+	ID    uint64 `json:"id"`
+	Clock int64  `json:"clock"`
+	NS    int64  `json:"ns"`
+}
+
+// AgentDataPacket пакет значений активных проверок
+type AgentDataPacket struct {
+	Request string        `json:"request"`
+	Session string        `json:"session"`
+	Data    []*AgentValue `json:"data"`
+	Clock   int64         `json:"clock"`
+	NS      int64         `json:"ns"`
+}
+
+// ParseDelay разбирает интервал обновления элемента данных (30, 30s, 5m, 1h, 1d, 1w).
+// Пользовательские интервалы после ";" не поддерживаются и игнорируются.
+func ParseDelay(delay string) (time.Duration, error) {
+	delay, _, _ = strings.Cut(strings.TrimSpace(delay), ";")
+	if delay == "" {
+		return 0, fmt.Errorf("empty delay")
+	}
+
+	unit := time.Second
+	switch delay[len(delay)-1] {
+	case 's':
+		delay = delay[:len(delay)-1]
+	case 'm':
+		unit, delay = time.Minute, delay[:len(delay)-1]
+	case 'h':
+		unit, delay = time.Hour, delay[:len(delay)-1]
+	case 'd':
+		unit, delay = 24*time.Hour, delay[:len(delay)-1]
+	case 'w':
+		unit, delay = 7*24*time.Hour, delay[:len(delay)-1]
+	}
+
+	value, err := strconv.ParseUint(delay, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid delay %q", delay)
+	}
+	return time.Duration(value) * unit, nil
+}
+
+// GetActiveChecks запрашивает у сервера список активных проверок хоста
+func (s *Sender) GetActiveChecks(ctx context.Context, host, metadata string) ([]ActiveCheck, error) {
+	res, err := s.request(ctx, &ActiveChecksRequest{
+		Request:      "active checks",
+		Host:         host,
+		HostMetadata: metadata,
+		Version:      AgentVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ActiveChecksResponse
+	if err := json.Unmarshal(res, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode active checks: %w", err)
+	}
+	if resp.Response != "success" {
+		return nil, fmt.Errorf("zabbix server returned %q: %s", resp.Response, resp.Info)
+	}
+
+	return resp.Data, nil
+}
+
+// SendAgentData отправляет значения активных проверок
+func (s *Sender) SendAgentData(ctx context.Context, packet *AgentDataPacket) (*SenderResponse, error) {
+	res, err := s.request(ctx, packet)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ParseResponse(res)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Response != "success" {
+		return resp, fmt.Errorf("zabbix server returned %q: %s", resp.Response, resp.Info)
+	}
+	return resp, nil
+}
+
+// ActiveSession нумерует значения активных проверок в рамках сессии агента.
+// Сервер использует сессию и номер значения, чтобы отбрасывать повторно отправленные значения.
+type ActiveSession struct {
+	host  string
+	token string
+
+	mu     sync.Mutex
+	lastID uint64
+}
+
+// NewActiveSession создает сессию активного агента со случайным идентификатором
+func NewActiveSession(host string) (*ActiveSession, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return &ActiveSession{host: host, token: hex.EncodeToString(token)}, nil
+}
+
+// Value создает значение активной проверки со следующим номером
+func (s *ActiveSession) Value(key, value string, state int, timestamp time.Time) *AgentValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	return &AgentValue{
+		Host:  s.host,
+		Key:   key,
+		Value: value,
+		State: state,
+		ID:    s.lastID,
+		Clock: timestamp.Unix(),
+		NS:    int64(timestamp.Nanosecond()),
+	}
+}
+
+// Packet создает пакет значений сессии
+func (s *ActiveSession) Packet(values []*AgentValue) *AgentDataPacket {
+	now := time.Now()
+	return &AgentDataPacket{
+		Request: "agent data",
+		Session: s.token,
+		Data:    values,
+		Clock:   now.Unix(),
+		NS:      int64(now.Nanosecond()),
+	}
+}
+
+// InitSenders подготавливает соединения с trapper без обращения к API.
+// Используется в активном режиме, где элементы данных настраиваются в интерфейсе Zabbix.
+func (c *Client) InitSenders() error {
+	return c.initSenders()
+}
+
+// GetActiveChecks запрашивает список активных проверок у первого доступного сервера
+func (c *Client) GetActiveChecks(ctx context.Context, host, metadata string) ([]ActiveCheck, error) {
+	var checks []ActiveCheck
+	_, err := c.dispatch(ctx, PolicyFailover, func(ctx context.Context, sender *Sender) (*SenderResponse, error) {
+		var err error
+		checks, err = sender.GetActiveChecks(ctx, host, metadata)
+		return nil, err
+	})
+	return checks, err
+}
+
+// SendAgentData отправляет значения активных проверок первому доступному серверу.
+// Значения разбиваются на пакеты по количеству и размеру так же, как в режиме trapper.
+func (c *Client) SendAgentData(ctx context.Context, session *ActiveSession, values []*AgentValue) (*SenderResponse, error) {
+	merged := &SenderResponse{Response: "success"}
+	for _, chunk := range splitChunks(values, c.batch.Size, c.batch.MaxBytes) {
+		packet := session.Packet(chunk)
+		resp, err := c.dispatch(ctx, PolicyFailover, func(ctx context.Context, sender *Sender) (*SenderResponse, error) {
+			return sender.SendAgentData(ctx, packet)
+		})
+		if err != nil {
+			return merged, fmt.Errorf("failed to send agent data: %w", err)
+		}
+		merged.Merge(resp)
+	}
+	return merged, nil
+}
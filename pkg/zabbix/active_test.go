@@ -0,0 +1,226 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// activeStub сервер, отдающий активные проверки и принимающий agent data
+type activeStub struct {
+	t      *testing.T
+	checks []ActiveCheck
+
+	mu       sync.Mutex
+	requests []ActiveChecksRequest
+	packets  []AgentDataPacket
+}
+
+func (s *activeStub) reply(request []byte) []byte {
+	var probe struct {
+		Request string `json:"request"`
+	}
+	if err := json.Unmarshal(request, &probe); err != nil {
+		s.t.Errorf("stub got invalid request: %v", err)
+		return []byte(`{"response":"failed"}`)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch probe.Request {
+	case "active checks":
+		var req ActiveChecksRequest
+		json.Unmarshal(request, &req)
+		s.requests = append(s.requests, req)
+		data, _ := json.Marshal(ActiveChecksResponse{Response: "success", Data: s.checks})
+		return data
+	case "agent data":
+		var packet AgentDataPacket
+		json.Unmarshal(request, &packet)
+		s.packets = append(s.packets, packet)
+		return senderReply(len(packet.Data), 0)
+	}
+	s.t.Errorf("stub got unexpected request %q", probe.Request)
+	return []byte(`{"response":"failed","info":"unknown request"}`)
+}
+
+func TestParseDelay(t *testing.T) {
+	tests := []struct {
+		delay   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{delay: "30", want: 30 * time.Second},
+		{delay: "30s", want: 30 * time.Second},
+		{delay: "5m", want: 5 * time.Minute},
+		{delay: "1h", want: time.Hour},
+		{delay: "1d", want: 24 * time.Hour},
+		{delay: "1w", want: 7 * 24 * time.Hour},
+		{delay: "1m;50s/1-7,00:00-24:00", want: time.Minute},
+		{delay: "", wantErr: true},
+		{delay: "{$DELAY}", wantErr: true},
+		{delay: "-5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDelay(tt.delay)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDelay(%q) error = %v, want error %v", tt.delay, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDelay(%q) = %v, want %v", tt.delay, got, tt.want)
+		}
+	}
+}
+
+func TestActiveChecksAndAgentData(t *testing.T) {
+	stub := &activeStub{
+		t: t,
+		checks: []ActiveCheck{
+			{Key: "system.cpu.util[,idle]", Delay: "30s", ItemID: 1},
+			{Key: "vm.memory.util", Delay: "1m", ItemID: 2},
+		},
+	}
+	sender := startTrapper(t, stub.reply)
+	client := newTestClient(t, sender)
+	client.SetBatchConfig(BatchConfig{Size: 2})
+	ctx := context.Background()
+
+	checks, err := client.GetActiveChecks(ctx, "web-1", "Linux")
+	if err != nil {
+		t.Fatalf("GetActiveChecks: %v", err)
+	}
+	if len(checks) != 2 || checks[0].Key != "system.cpu.util[,idle]" || checks[1].Delay != "1m" {
+		t.Errorf("checks = %+v", checks)
+	}
+	req := stub.requests[0]
+	if req.Host != "web-1" || req.HostMetadata != "Linux" || req.Version != AgentVersion {
+		t.Errorf("active checks request = %+v", req)
+	}
+
+	session, err := NewActiveSession("web-1")
+	if err != nil {
+		t.Fatalf("NewActiveSession: %v", err)
+	}
+	other, _ := NewActiveSession("web-1")
+
+	timestamp := time.Unix(1700000000, 123456789)
+	var sent []*AgentValue
+	for round := 0; round < 2; round++ {
+		values := []*AgentValue{
+			session.Value("system.cpu.util[,idle]", "97.5", StateNormal, timestamp),
+			session.Value("vm.memory.util", "41.2", StateNormal, timestamp),
+			session.Value("unknown.key", "Unsupported item key.", StateNotSupported, timestamp),
+		}
+		sent = append(sent, values...)
+
+		resp, err := client.SendAgentData(ctx, session, values)
+		if err != nil {
+			t.Fatalf("SendAgentData: %v", err)
+		}
+		if resp.Processed != 3 || resp.Failed != 0 {
+			t.Errorf("response = %+v, want 3 processed", resp)
+		}
+	}
+
+	// Три значения при размере пакета 2 - по два пакета на отправку
+	if len(stub.packets) != 4 {
+		t.Fatalf("stub received %d packets, want 4", len(stub.packets))
+	}
+
+	var lastID uint64
+	received := 0
+	for _, packet := range stub.packets {
+		if packet.Request != "agent data" {
+			t.Errorf("request = %q, want agent data", packet.Request)
+		}
+		if packet.Session != session.token || len(packet.Session) != 32 {
+			t.Errorf("session = %q, want %q", packet.Session, session.token)
+		}
+		if packet.Clock == 0 {
+			t.Errorf("packet clock is not set")
+		}
+
+		for _, value := range packet.Data {
+			if value.ID <= lastID {
+				t.Errorf("value id %d does not increase after %d", value.ID, lastID)
+			}
+			lastID = value.ID
+			if value.Host != "web-1" {
+				t.Errorf("value host = %q, want web-1", value.Host)
+			}
+			if value.Clock != 1700000000 || value.NS != 123456789 {
+				t.Errorf("value clock = %d.%d, want 1700000000.123456789", value.Clock, value.NS)
+			}
+			received++
+		}
+	}
+	if received != len(sent) || lastID != uint64(len(sent)) {
+		t.Errorf("received %d values with last id %d, want %d", received, lastID, len(sent))
+	}
+
+	if unsupported := stub.packets[1].Data[0]; unsupported.State != StateNotSupported || unsupported.Key != "unknown.key" {
+		t.Errorf("not supported value = %+v", unsupported)
+	}
+	if other.token == session.token {
+		t.Errorf("sessions share token %q", session.token)
+	}
+}
+
+func TestSendAgentDataMaxBytes(t *testing.T) {
+	stub := &activeStub{t: t}
+	sender := startTrapper(t, stub.reply)
+	client := newTestClient(t, sender)
+	client.SetBatchConfig(BatchConfig{Size: 100, MaxBytes: 4096})
+
+	session, _ := NewActiveSession("web-1")
+	large := strings.Repeat("x", 1500)
+	var values []*AgentValue
+	for i := 0; i < 6; i++ {
+		values = append(values, session.Value("vfs.fs.discovery", large, StateNormal, time.Now()))
+	}
+
+	if _, err := client.SendAgentData(context.Background(), session, values); err != nil {
+		t.Fatalf("SendAgentData: %v", err)
+	}
+
+	// Каждый пакет укладывается в лимит, значения не теряются
+	received := 0
+	for _, packet := range stub.packets {
+		data, _ := json.Marshal(packet)
+		if len(data) > 4096 {
+			t.Errorf("packet size %d exceeds limit 4096", len(data))
+		}
+		received += len(packet.Data)
+	}
+	if len(stub.packets) < 3 || received != len(values) {
+		t.Errorf("stub received %d values in %d packets, want %d values in at least 3 packets",
+			received, len(stub.packets), len(values))
+	}
+}
+
+func TestMatchPrototype(t *testing.T) {
+	tests := []struct {
+		prototype string
+		key       string
+		want      bool
+	}{
+		{prototype: "vfs.fs.size[{#FSNAME},total]", key: "vfs.fs.size[/,total]", want: true},
+		{prototype: "vfs.fs.size[{#FSNAME},total]", key: `vfs.fs.size["/mnt/a,b",total]`, want: true},
+		{prototype: "vfs.fs.size[{#FSNAME},total]", key: "vfs.fs.size[/,free]"},
+		{prototype: "vfs.fs.size[{#FSNAME},total]", key: "vfs.fs.size[/]"},
+		{prototype: "system.cpu.util[{#CPU.NUMBER},user]", key: "system.cpu.util[3,user]", want: true},
+		{prototype: "net.if.in[{#IFNAME}]", key: "net.if.out[eth0]"},
+	}
+
+	for _, tt := range tests {
+		if got := matchPrototype(tt.prototype, tt.key); got != tt.want {
+			t.Errorf("matchPrototype(%q, %q) = %v, want %v", tt.prototype, tt.key, got, tt.want)
+		}
+	}
+}
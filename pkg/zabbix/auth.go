@@ -0,0 +1,101 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Методы API, которые сервер отклоняет, если запрос содержит токен
+const (
+	methodAPIVersion = "apiinfo.version"
+	methodUserLogin  = "user.login"
+)
+
+// apiVersion версия Zabbix API (major.minor)
+type apiVersion struct {
+	major int
+	minor int
+}
+
+// parseAPIVersion разбирает версию вида 7.0.3
+func parseAPIVersion(version string) (apiVersion, error) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return apiVersion{}, fmt.Errorf("invalid API version %q", version)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return apiVersion{}, fmt.Errorf("invalid API version %q", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return apiVersion{}, fmt.Errorf("invalid API version %q", version)
+	}
+
+	return apiVersion{major: major, minor: minor}, nil
+}
+
+// atLeast проверяет, что версия не ниже major.minor
+func (v apiVersion) atLeast(major, minor int) bool {
+	return v.major > major || v.major == major && v.minor >= minor
+}
+
+// isZero проверяет, определена ли версия
+func (v apiVersion) isZero() bool {
+	return v == apiVersion{}
+}
+
+func (v apiVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// Возможности API, зависящие от версии:
+//   - 5.4: API токены, параметр username в user.login вместо user,
+//     синтаксис выражений триггеров func(/host/key,...);
+//   - 6.4: заголовок Authorization вместо устаревшего поля auth (удалено в 7.0).
+func (v apiVersion) supportsTokens() bool     { return v.atLeast(5, 4) }
+func (v apiVersion) usesUsername() bool       { return v.atLeast(5, 4) }
+func (v apiVersion) usesNewExpressions() bool { return v.atLeast(5, 4) }
+func (v apiVersion) usesBearerHeader() bool   { return v.atLeast(6, 4) }
+
+// SetAPIToken задает API токен, который используется вместо имени пользователя и пароля
+func (c *Client) SetAPIToken(token string) {
+	c.apiToken = token
+}
+
+// detectAPIVersion определяет версию API один раз за время работы клиента
+func (c *Client) detectAPIVersion(ctx context.Context) (apiVersion, error) {
+	c.authMutex.RLock()
+	version := c.apiVersion
+	c.authMutex.RUnlock()
+	if !version.isZero() {
+		return version, nil
+	}
+
+	resp, err := c.makeRequest(ctx, methodAPIVersion, []string{})
+	if err != nil {
+		return apiVersion{}, fmt.Errorf("failed to get API version: %w", err)
+	}
+
+	var raw string
+	if err := json.Unmarshal(resp.Result, &raw); err != nil {
+		return apiVersion{}, fmt.Errorf("failed to parse API version: %w", err)
+	}
+	version, err = parseAPIVersion(raw)
+	if err != nil {
+		return apiVersion{}, err
+	}
+
+	c.authMutex.Lock()
+	c.apiVersion = version
+	c.authMutex.Unlock()
+
+	c.logger.Info("Detected Zabbix API version", zap.String("version", raw))
+	return version, nil
+}
@@ -3,10 +3,14 @@ package zabbix
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -15,6 +19,33 @@ import (
 	"go.uber.org/zap"
 )
 
+// BatchConfig содержит настройки разбиения значений на пакеты Sender
+type BatchConfig struct {
+	Size        int // максимальное количество значений в пакете (0 - без ограничения)
+	MaxBytes    int // максимальный размер пакета в байтах (0 - без ограничения)
+	Parallelism int // количество пакетов, отправляемых одновременно
+}
+
+// SenderConfig содержит настройки соединения Zabbix Sender
+type SenderConfig struct {
+	Servers []string // адреса trapper host:port (пусто - хост из URL API и порт 10051)
+	Policy  string   // политика доставки при нескольких адресах (failover, fanout)
+
+	BreakerThreshold int           // количество ошибок подряд, после которого адрес пропускается
+	BreakerCooldown  time.Duration // время, на которое пропускается недоступный адрес
+
+	Compress bool        // сжимать пакеты zlib
+	TLS      *tls.Config // шифрование соединения (nil - без шифрования)
+
+	DialTimeout  time.Duration // таймаут установки соединения
+	ReadTimeout  time.Duration // таймаут чтения ответа
+	WriteTimeout time.Duration // таймаут записи пакета
+	PoolSize     int           // количество удерживаемых соединений (0 - без пула)
+}
+
+// itemsRefreshInterval минимальный интервал между перезагрузками элементов данных
+const itemsRefreshInterval = time.Minute
+
 // Client представляет клиент для работы с Zabbix API
 type Client struct {
 	url        string
@@ -23,18 +54,41 @@ type Client struct {
 	httpClient *http.Client
 	logger     *zap.Logger
 
-	authToken  string
+	apiToken   string     // API токен (пусто - вход по имени пользователя и паролю)
+	authToken  string     // токен, которым подписываются запросы
+	apiVersion apiVersion // версия API, определяется при первом входе
 	authMutex  sync.RWMutex
 	hostID     string
 	hostName   string            // Добавляем имя хоста для sender
 	items      map[string]string // key -> itemID mapping
 	itemsMutex sync.RWMutex
 
+	discoveryRules map[string]string // key -> discovery rule itemID mapping
+	itemsLoadedAt  time.Time
+
+	// Настройки разбиения значений на пакеты
+	batch BatchConfig
+
+	// Настройки соединения Sender
+	senderConfig SenderConfig
+
+	// Настройки автоматической регистрации хоста
+	hostConfig HostConfig
+
+	// Триггеры, создаваемые вместе с элементами данных
+	triggers []ZabbixTrigger
+
+	// Настройки приведения элементов данных к каталогу
+	reconcile ReconcileConfig
+
+	// Ключи, которые публикует сборщик (nil - весь каталог)
+	enabledKeys map[string]bool
+
 	requestID int
 	idMutex   sync.Mutex
 
-	// Zabbix Sender для отправки данных
-	sender *Sender
+	// Адреса trapper в порядке приоритета
+	targets []*target
 }
 
 // NewClient создает новый Zabbix клиент
@@ -46,11 +100,35 @@ func NewClient(url, user, password string, timeout time.Duration, logger *zap.Lo
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
-		logger: logger,
-		items:  make(map[string]string),
+		logger:         logger,
+		items:          make(map[string]string),
+		discoveryRules: make(map[string]string),
+	}
+}
+
+// SetBatchConfig задает настройки разбиения значений на пакеты
+func (c *Client) SetBatchConfig(batch BatchConfig) {
+	c.batch = batch
+}
+
+// SetSenderConfig задает настройки соединения Zabbix Sender
+func (c *Client) SetSenderConfig(cfg SenderConfig) {
+	c.senderConfig = cfg
+}
+
+// SetEnabledKeys ограничивает создаваемые элементы данных ключами включенных источников
+func (c *Client) SetEnabledKeys(keys []string) {
+	c.enabledKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		c.enabledKeys[key] = true
 	}
 }
 
+// isKeyEnabled проверяет, публикуется ли ключ включенными источниками
+func (c *Client) isKeyEnabled(key string) bool {
+	return c.enabledKeys == nil || c.enabledKeys[key]
+}
+
 // getZabbixServerHost извлекает хост сервера из URL API
 func (c *Client) getZabbixServerHost() (string, error) {
 	u, err := url.Parse(c.url)
@@ -78,15 +156,23 @@ func (c *Client) getNextRequestID() int {
 func (c *Client) makeRequest(ctx context.Context, method string, params interface{}) (*JSONRPCResponse, error) {
 	c.authMutex.RLock()
 	authToken := c.authToken
+	bearer := c.apiVersion.usesBearerHeader()
 	c.authMutex.RUnlock()
 
+	// Методы входа отклоняются сервером, если запрос содержит токен (в том числе устаревший)
+	if method == methodAPIVersion || method == methodUserLogin {
+		authToken = ""
+	}
+
 	request := JSONRPCRequest{
 		JSONRPC: "2.0",
 		Method:  method,
 		Params:  params,
-		Auth:    authToken,
 		ID:      c.getNextRequestID(),
 	}
+	if !bearer {
+		request.Auth = authToken
+	}
 
 	jsonData, err := json.Marshal(request)
 	if err != nil {
@@ -103,6 +189,9 @@ func (c *Client) makeRequest(ctx context.Context, method string, params interfac
 	}
 
 	req.Header.Set("Content-Type", "application/json-rpc")
+	if bearer && authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -120,23 +209,43 @@ func (c *Client) makeRequest(ctx context.Context, method string, params interfac
 	}
 
 	if response.Error != nil {
-		return nil, fmt.Errorf("zabbix API error: %s (code: %d, data: %s)",
-			response.Error.Message, response.Error.Code, response.Error.Data)
+		return nil, fmt.Errorf("%s: %w", method, response.Error)
 	}
 
 	return &response, nil
 }
 
-// Login выполняет аутентификацию в Zabbix
+// Login выполняет аутентификацию в Zabbix. Способ передачи токена и параметры
+// user.login выбираются по версии API. С API токеном вход не выполняется.
 func (c *Client) Login(ctx context.Context) error {
+	version, err := c.detectAPIVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.apiToken != "" {
+		if !version.supportsTokens() {
+			return fmt.Errorf("API tokens require Zabbix 5.4 or newer, server API version is %s", version)
+		}
+
+		c.authMutex.Lock()
+		c.authToken = c.apiToken
+		c.authMutex.Unlock()
+
+		c.logger.Info("Using Zabbix API token for authentication")
+		return nil
+	}
+
 	c.logger.Info("Authenticating with Zabbix", zap.String("user", c.user))
 
-	params := LoginParams{
-		User:     c.user,
-		Password: c.password,
+	params := LoginParams{Password: c.password}
+	if version.usesUsername() {
+		params.Username = c.user
+	} else {
+		params.User = c.user
 	}
 
-	resp, err := c.makeRequest(ctx, "user.login", params)
+	resp, err := c.makeRequest(ctx, methodUserLogin, params)
 	if err != nil {
 		return fmt.Errorf("login failed: %w", err)
 	}
@@ -154,7 +263,8 @@ func (c *Client) Login(ctx context.Context) error {
 	return nil
 }
 
-// findHost ищет хост по имени
+// findHost ищет хост по имени. В режиме автоматической регистрации отсутствующий хост
+// создается, а у найденного синхронизируются теги и шаблоны.
 func (c *Client) findHost(ctx context.Context, hostName string) error {
 	c.logger.Info("Finding host in Zabbix", zap.String("host", hostName))
 
@@ -164,6 +274,10 @@ func (c *Client) findHost(ctx context.Context, hostName string) error {
 			"host": hostName,
 		},
 	}
+	if c.hostConfig.AutoRegister {
+		params.SelectTags = []string{"tag", "value"}
+		params.SelectParentTemplates = []string{"templateid", "host"}
+	}
 
 	resp, err := c.makeRequest(ctx, "host.get", params)
 	if err != nil {
@@ -175,7 +289,18 @@ func (c *Client) findHost(ctx context.Context, hostName string) error {
 		return fmt.Errorf("failed to parse hosts: %w", err)
 	}
 
-	if len(hosts) == 0 {
+	switch {
+	case len(hosts) > 0 && c.hostConfig.AutoRegister:
+		if err := c.syncHost(ctx, &hosts[0]); err != nil {
+			return err
+		}
+	case len(hosts) == 0 && c.hostConfig.AutoRegister:
+		host, err := c.registerHost(ctx, hostName)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, *host)
+	case len(hosts) == 0:
 		return fmt.Errorf("host '%s' not found in Zabbix", hostName)
 	}
 
@@ -189,7 +314,7 @@ func (c *Client) findHost(ctx context.Context, hostName string) error {
 	return nil
 }
 
-// loadItems загружает существующие элементы данных для хоста
+// loadItems загружает существующие элементы данных и правила обнаружения для хоста
 func (c *Client) loadItems(ctx context.Context) error {
 	c.logger.Info("Loading existing items from Zabbix")
 
@@ -208,6 +333,20 @@ func (c *Client) loadItems(ctx context.Context) error {
 		return fmt.Errorf("failed to parse items: %w", err)
 	}
 
+	// Правила обнаружения тоже принимают данные через trapper
+	rulesResp, err := c.makeRequest(ctx, "discoveryrule.get", DiscoveryRuleGetParams{
+		Output:  []string{"itemid", "name", "key_"},
+		HostIDs: []string{c.hostID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get discovery rules: %w", err)
+	}
+
+	var rules []DiscoveryRule
+	if err := json.Unmarshal(rulesResp.Result, &rules); err != nil {
+		return fmt.Errorf("failed to parse discovery rules: %w", err)
+	}
+
 	c.itemsMutex.Lock()
 	defer c.itemsMutex.Unlock()
 
@@ -216,19 +355,65 @@ func (c *Client) loadItems(ctx context.Context) error {
 		c.items[item.Key] = item.ItemID
 	}
 
-	c.logger.Info("Loaded items", zap.Int("count", len(items)))
+	c.discoveryRules = make(map[string]string)
+	for _, rule := range rules {
+		c.items[rule.Key] = rule.ItemID
+		c.discoveryRules[rule.Key] = rule.ItemID
+	}
+	c.itemsLoadedAt = time.Now()
+
+	c.logger.Info("Loaded items",
+		zap.Int("count", len(items)),
+		zap.Int("discovery_rules", len(rules)))
 	return nil
 }
 
-// createMissingItems создает отсутствующие элементы данных
-func (c *Client) createMissingItems(ctx context.Context) error {
+// refreshItemsIfNeeded перезагружает элементы данных, если в наборе есть неизвестные ключи.
+// Элементы, созданные правилами обнаружения, появляются в Zabbix после отправки данных обнаружения.
+func (c *Client) refreshItemsIfNeeded(ctx context.Context, metrics *collector.MetricSet) error {
+	c.itemsMutex.RLock()
+	stale := time.Since(c.itemsLoadedAt) >= itemsRefreshInterval
+	unknown := 0
+	if stale {
+		for _, key := range metrics.Keys() {
+			if _, exists := c.items[key]; !exists {
+				unknown++
+			}
+		}
+	}
+	c.itemsMutex.RUnlock()
+
+	if unknown == 0 {
+		return nil
+	}
+
+	c.logger.Debug("Reloading items for unknown keys", zap.Int("unknown", unknown))
+	if err := c.loadItems(ctx); err != nil {
+		c.logger.Warn("Failed to reload items", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// createMissingItems создает отсутствующие элементы данных.
+// Если заданы keys, создаются только элементы с этими ключами.
+// Возвращает количество созданных элементов, в режиме проверки ноль.
+func (c *Client) createMissingItems(ctx context.Context, keys ...string) (int, error) {
 	c.logger.Info("Creating missing items")
 
+	only := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		only[key] = true
+	}
+
 	zabbixItems := GetZabbixItems()
 	var itemsToCreate []ItemCreateParams
 
 	c.itemsMutex.RLock()
 	for _, zItem := range zabbixItems {
+		if !c.isKeyEnabled(zItem.Key) || len(only) > 0 && !only[zItem.Key] {
+			continue
+		}
 		if _, exists := c.items[zItem.Key]; !exists {
 			itemsToCreate = append(itemsToCreate, ItemCreateParams{
 				Name:        zItem.Name,
@@ -238,7 +423,9 @@ func (c *Client) createMissingItems(ctx context.Context) error {
 				ValueType:   zItem.ValueType,
 				DataType:    0, // decimal
 				Description: zItem.Description,
+				Units:       zItem.Units,
 				Status:      0, // enabled
+				Tags:        c.managedTags(),
 			})
 		}
 	}
@@ -246,24 +433,31 @@ func (c *Client) createMissingItems(ctx context.Context) error {
 
 	if len(itemsToCreate) == 0 {
 		c.logger.Info("All items already exist")
-		return nil
+		return 0, nil
+	}
+
+	if c.dryRun() {
+		for _, item := range itemsToCreate {
+			c.logger.Info("Item will be created (dry run)", zap.String("key", item.Key))
+		}
+		return 0, nil
 	}
 
 	c.logger.Info("Creating items", zap.Int("count", len(itemsToCreate)))
 
 	resp, err := c.makeRequest(ctx, "item.create", itemsToCreate)
 	if err != nil {
-		return fmt.Errorf("failed to create items: %w", err)
+		return 0, fmt.Errorf("failed to create items: %w", err)
 	}
 
 	var result map[string][]string
 	if err := json.Unmarshal(resp.Result, &result); err != nil {
-		return fmt.Errorf("failed to parse create result: %w", err)
+		return 0, fmt.Errorf("failed to parse create result: %w", err)
 	}
 
 	itemIDs := result["itemids"]
 	if len(itemIDs) != len(itemsToCreate) {
-		return fmt.Errorf("unexpected number of created items: got %d, expected %d",
+		return 0, fmt.Errorf("unexpected number of created items: got %d, expected %d",
 			len(itemIDs), len(itemsToCreate))
 	}
 
@@ -275,6 +469,206 @@ func (c *Client) createMissingItems(ctx context.Context) error {
 	c.itemsMutex.Unlock()
 
 	c.logger.Info("Successfully created items", zap.Int("count", len(itemIDs)))
+	return len(itemIDs), nil
+}
+
+// createMissingDiscoveryRules создает отсутствующие правила обнаружения и их прототипы
+func (c *Client) createMissingDiscoveryRules(ctx context.Context) error {
+	c.logger.Info("Creating missing discovery rules")
+
+	for _, rule := range GetZabbixDiscoveryRules() {
+		if !c.isKeyEnabled(rule.Key) {
+			continue
+		}
+
+		c.itemsMutex.RLock()
+		ruleID, exists := c.discoveryRules[rule.Key]
+		c.itemsMutex.RUnlock()
+
+		// Элементы прежних версий мешают обнаружению элементов с теми же ключами
+		if err := c.migrateLegacyItems(ctx, rule.Key); err != nil {
+			return fmt.Errorf("failed to migrate legacy items for %s: %w", rule.Key, err)
+		}
+
+		if !exists && c.dryRun() {
+			c.logger.Info("Discovery rule will be created (dry run)",
+				zap.String("key", rule.Key),
+				zap.Int("prototypes", len(rule.Prototypes)))
+			continue
+		}
+
+		if !exists {
+			params := DiscoveryRuleCreateParams{
+				Name:        rule.Name,
+				Key:         rule.Key,
+				HostID:      c.hostID,
+				Type:        2, // Zabbix trapper
+				Lifetime:    "7d",
+				Description: rule.Description,
+				Status:      0, // enabled
+			}
+
+			resp, err := c.makeRequest(ctx, "discoveryrule.create", params)
+			if err != nil {
+				return fmt.Errorf("failed to create discovery rule %s: %w", rule.Key, err)
+			}
+
+			var result map[string][]string
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				return fmt.Errorf("failed to parse create result: %w", err)
+			}
+			if len(result["itemids"]) != 1 {
+				return fmt.Errorf("unexpected number of created discovery rules: got %d, expected 1",
+					len(result["itemids"]))
+			}
+
+			ruleID = result["itemids"][0]
+			c.itemsMutex.Lock()
+			c.items[rule.Key] = ruleID
+			c.discoveryRules[rule.Key] = ruleID
+			c.itemsMutex.Unlock()
+
+			c.logger.Info("Created discovery rule", zap.String("key", rule.Key))
+		}
+
+		if err := c.createMissingItemPrototypes(ctx, ruleID, rule.Prototypes); err != nil {
+			return fmt.Errorf("failed to create item prototypes for %s: %w", rule.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// legacyItems ключи элементов данных прежних версий, которые совпадают с ключами,
+// создаваемыми правилом обнаружения. Zabbix не создает элемент обнаружения, если на хосте
+// уже есть элемент с тем же ключом.
+var legacyItems = map[string][]string{
+	"vfs.fs.discovery": {"vfs.fs.size[/,total]", "vfs.fs.size[/,used]", "vfs.fs.size[/,free]", "vfs.fs.pused[/]"},
+}
+
+// migrateLegacyItems сообщает об элементах данных прежних версий, мешающих правилу обнаружения.
+// Пока миграция не включена, элементы сохраняются и продолжают получать значения, а обнаружение
+// пропускает их ключи. С миграцией элементы удаляются вместе с историей; элементы шаблонов
+// не удаляются никогда.
+func (c *Client) migrateLegacyItems(ctx context.Context, ruleKey string) error {
+	legacy := make(map[string]bool)
+	for _, key := range legacyItems[ruleKey] {
+		legacy[key] = true
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	resp, err := c.makeRequest(ctx, "item.get", ItemGetParams{
+		Output:  []string{"itemid", "key_", "flags", "templateid"},
+		HostIDs: []string{c.hostID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get items: %w", err)
+	}
+
+	var items []Item
+	if err := json.Unmarshal(resp.Result, &items); err != nil {
+		return fmt.Errorf("failed to parse items: %w", err)
+	}
+
+	var ids, keys []string
+	for _, item := range items {
+		if !legacy[item.Key] || item.Flags != "0" {
+			continue
+		}
+		if item.TemplateID != "0" {
+			c.logger.Warn("Legacy item is inherited from a template and conflicts with discovery, unlink the template",
+				zap.String("key", item.Key),
+				zap.String("rule", ruleKey))
+			continue
+		}
+		ids = append(ids, item.ItemID)
+		keys = append(keys, item.Key)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if !c.reconcile.MigrateLegacy {
+		c.logger.Warn("Legacy items conflict with discovery and are kept, run with --migrate-legacy-items to delete them with their history",
+			zap.String("rule", ruleKey),
+			zap.Strings("keys", keys))
+		return nil
+	}
+	if c.dryRun() {
+		c.logger.Info("Legacy items will be deleted (dry run)", zap.String("rule", ruleKey), zap.Strings("keys", keys))
+		return nil
+	}
+
+	if _, err := c.makeRequest(ctx, "item.delete", ids); err != nil {
+		return fmt.Errorf("failed to delete items: %w", err)
+	}
+
+	c.itemsMutex.Lock()
+	for _, key := range keys {
+		delete(c.items, key)
+	}
+	c.itemsMutex.Unlock()
+
+	c.logger.Warn("Deleted legacy items replaced by discovery", zap.String("rule", ruleKey), zap.Strings("keys", keys))
+	return nil
+}
+
+// createMissingItemPrototypes создает отсутствующие прототипы элементов данных правила обнаружения
+func (c *Client) createMissingItemPrototypes(ctx context.Context, ruleID string, prototypes []ZabbixMetricItem) error {
+	resp, err := c.makeRequest(ctx, "itemprototype.get", ItemPrototypeGetParams{
+		Output:       []string{"itemid", "name", "key_"},
+		DiscoveryIDs: []string{ruleID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get item prototypes: %w", err)
+	}
+
+	var existing []ItemPrototype
+	if err := json.Unmarshal(resp.Result, &existing); err != nil {
+		return fmt.Errorf("failed to parse item prototypes: %w", err)
+	}
+
+	existingKeys := make(map[string]bool, len(existing))
+	for _, prototype := range existing {
+		existingKeys[prototype.Key] = true
+	}
+
+	var toCreate []ItemPrototypeCreateParams
+	for _, prototype := range prototypes {
+		if existingKeys[prototype.Key] {
+			continue
+		}
+		toCreate = append(toCreate, ItemPrototypeCreateParams{
+			Name:        prototype.Name,
+			Key:         prototype.Key,
+			HostID:      c.hostID,
+			RuleID:      ruleID,
+			Type:        2, // Zabbix trapper
+			ValueType:   prototype.ValueType,
+			Description: prototype.Description,
+			Units:       prototype.Units,
+			Status:      0, // enabled
+		})
+	}
+
+	if len(toCreate) == 0 {
+		return nil
+	}
+
+	if c.dryRun() {
+		for _, prototype := range toCreate {
+			c.logger.Info("Item prototype will be created (dry run)", zap.String("key", prototype.Key))
+		}
+		return nil
+	}
+
+	if _, err := c.makeRequest(ctx, "itemprototype.create", toCreate); err != nil {
+		return fmt.Errorf("failed to create item prototypes: %w", err)
+	}
+
+	c.logger.Info("Created item prototypes", zap.Int("count", len(toCreate)))
 	return nil
 }
 
@@ -292,50 +686,401 @@ func (c *Client) Initialize(ctx context.Context, hostName string) error {
 		return fmt.Errorf("failed to find host: %w", err)
 	}
 
+	// Приведение существующих элементов к каталогу
+	if c.reconcile.Enabled {
+		if _, err := c.reconcileItems(ctx); err != nil {
+			return fmt.Errorf("failed to reconcile items: %w", err)
+		}
+	}
+
 	// Загрузка существующих элементов
 	if err := c.loadItems(ctx); err != nil {
 		return fmt.Errorf("failed to load items: %w", err)
 	}
 
 	// Создание недостающих элементов
-	if err := c.createMissingItems(ctx); err != nil {
+	if _, err := c.createMissingItems(ctx); err != nil {
 		return fmt.Errorf("failed to create missing items: %w", err)
 	}
 
-	// Инициализируем Zabbix Sender
-	serverHost, err := c.getZabbixServerHost()
-	if err != nil {
-		return fmt.Errorf("failed to get zabbix server host: %w", err)
+	// Создание недостающих правил обнаружения
+	if err := c.createMissingDiscoveryRules(ctx); err != nil {
+		return fmt.Errorf("failed to create missing discovery rules: %w", err)
 	}
 
-	c.sender = NewSender(serverHost, 10051)
-	c.logger.Info("Initialized Zabbix Sender", zap.String("server", serverHost))
+	// Создание и обновление триггеров
+	if len(c.triggers) > 0 {
+		if err := c.syncTriggers(ctx); err != nil {
+			return fmt.Errorf("failed to synchronize triggers: %w", err)
+		}
+	}
+
+	// Инициализируем Zabbix Sender
+	if err := c.initSenders(); err != nil {
+		return err
+	}
 
 	c.logger.Info("Zabbix client initialized successfully")
 	return nil
 }
 
+// initSenders создает Zabbix Sender для каждого адреса trapper.
+// Адреса не меняются во время работы, поэтому при повторной инициализации
+// сохраняются состояние выключателей и пул соединений.
+func (c *Client) initSenders() error {
+	if len(c.targets) > 0 {
+		return nil
+	}
+
+	addresses := c.senderConfig.Servers
+	if len(addresses) == 0 {
+		serverHost, err := c.getZabbixServerHost()
+		if err != nil {
+			return fmt.Errorf("failed to get zabbix server host: %w", err)
+		}
+		addresses = []string{net.JoinHostPort(serverHost, strconv.Itoa(DefaultPort))}
+	}
+
+	targets := make([]*target, 0, len(addresses))
+	for _, address := range addresses {
+		host, port, err := ParseAddress(address)
+		if err != nil {
+			return err
+		}
+
+		sender := NewSender(host, port)
+		sender.Compress = c.senderConfig.Compress
+		sender.TLS = c.senderConfig.TLS
+		sender.DialTimeout = c.senderConfig.DialTimeout
+		sender.ReadTimeout = c.senderConfig.ReadTimeout
+		sender.WriteTimeout = c.senderConfig.WriteTimeout
+		sender.PoolSize = c.senderConfig.PoolSize
+
+		targets = append(targets, &target{
+			sender:  sender,
+			breaker: newCircuitBreaker(c.senderConfig.BreakerThreshold, c.senderConfig.BreakerCooldown),
+		})
+	}
+	c.targets = targets
+
+	c.logger.Info("Initialized Zabbix Sender",
+		zap.Strings("servers", addresses),
+		zap.String("policy", c.deliveryPolicy()),
+		zap.Bool("compress", c.senderConfig.Compress),
+		zap.Bool("tls", c.senderConfig.TLS != nil))
+	return nil
+}
+
+// deliveryPolicy возвращает политику доставки с учетом значения по умолчанию
+func (c *Client) deliveryPolicy() string {
+	if c.senderConfig.Policy == "" {
+		return PolicyFailover
+	}
+	return c.senderConfig.Policy
+}
+
+// isDeliveryError проверяет, что пакет не был доставлен.
+// Отклонение значений сервером не считается ошибкой доставки.
+func isDeliveryError(err error) bool {
+	var failedErr *FailedValuesError
+	return err != nil && !errors.As(err, &failedErr)
+}
+
+// sendFunc выполняет запрос к одному адресу trapper
+type sendFunc func(ctx context.Context, sender *Sender) (*SenderResponse, error)
+
+// send отправляет пакет согласно политике доставки
+func (c *Client) send(ctx context.Context, packet *Packet) (*SenderResponse, error) {
+	return c.dispatch(ctx, c.deliveryPolicy(), func(ctx context.Context, sender *Sender) (*SenderResponse, error) {
+		return sender.SendContext(ctx, packet)
+	})
+}
+
+// dispatch выполняет запрос к адресам trapper согласно политике
+func (c *Client) dispatch(ctx context.Context, policy string, do sendFunc) (*SenderResponse, error) {
+	if len(c.targets) == 0 {
+		return nil, errors.New("zabbix sender is not initialized")
+	}
+
+	if policy == PolicyFanout {
+		return c.sendFanout(ctx, do)
+	}
+	return c.sendFailover(ctx, do)
+}
+
+// sendTo выполняет запрос к одному адресу и учитывает результат в его выключателе
+func (c *Client) sendTo(ctx context.Context, t *target, do sendFunc) (*SenderResponse, error) {
+	resp, err := do(ctx, t.sender)
+	switch {
+	case !isDeliveryError(err):
+		t.record(nil)
+	case ctx.Err() != nil:
+		// Отмена не говорит о недоступности адреса
+		t.breaker.release()
+	default:
+		t.record(err)
+		c.logger.Warn("Failed to send data to Zabbix server",
+			zap.String("server", t.sender.Address()),
+			zap.String("breaker", t.breaker.currentState()),
+			zap.Error(err))
+	}
+	return resp, err
+}
+
+// sendFailover выполняет запрос к первому доступному адресу
+func (c *Client) sendFailover(ctx context.Context, do sendFunc) (*SenderResponse, error) {
+	var lastErr error
+	for _, t := range c.targets {
+		if !t.breaker.allow() {
+			t.skip()
+			continue
+		}
+
+		resp, err := c.sendTo(ctx, t, do)
+		if !isDeliveryError(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, errors.New("all zabbix servers are unavailable")
+	}
+	return nil, lastErr
+}
+
+// sendFanout выполняет запрос ко всем доступным адресам одновременно.
+// Запрос считается выполненным, если его принял хотя бы один адрес.
+func (c *Client) sendFanout(ctx context.Context, do sendFunc) (*SenderResponse, error) {
+	results := make([]chunkResult, len(c.targets))
+
+	var wg sync.WaitGroup
+	for i, t := range c.targets {
+		if !t.breaker.allow() {
+			t.skip()
+			results[i].err = fmt.Errorf("zabbix server %s is unavailable", t.sender.Address())
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, t *target) {
+			defer wg.Done()
+			results[i].resp, results[i].err = c.sendTo(ctx, t, do)
+		}(i, t)
+	}
+	wg.Wait()
+
+	// Возвращаем ответ адреса с наибольшим приоритетом из принявших пакет
+	for _, result := range results {
+		if !isDeliveryError(result.err) {
+			return result.resp, result.err
+		}
+	}
+	return nil, results[0].err
+}
+
+// TargetStatus возвращает состояние доставки на каждый адрес trapper
+func (c *Client) TargetStatus() []TargetStatus {
+	statuses := make([]TargetStatus, 0, len(c.targets))
+	for _, t := range c.targets {
+		statuses = append(statuses, t.status())
+	}
+	return statuses
+}
+
+// Close закрывает соединения Zabbix Sender
+func (c *Client) Close() {
+	for _, t := range c.targets {
+		t.sender.Close()
+	}
+}
+
 // SendMetrics отправляет метрики в Zabbix через Sender протокол
-func (c *Client) SendMetrics(ctx context.Context, metrics *collector.MetricSet) error {
-	c.logger.Debug("Sending metrics to Zabbix via Sender")
+func (c *Client) SendMetrics(ctx context.Context, metrics *collector.MetricSet) (*SenderResponse, error) {
+	// Ошибка перезагрузки элементов не мешает отправке известных значений
+	data, _ := c.PrepareMetrics(ctx, metrics)
+	return c.SendData(ctx, data)
+}
+
+// PrepareMetrics конвертирует собранные метрики в формат Zabbix Sender.
+// Значения возвращаются и при ошибке перезагрузки элементов данных, ошибка API
+// возвращается вместе с ними, чтобы вызывающий код мог выполнить повторный вход.
+func (c *Client) PrepareMetrics(ctx context.Context, metrics *collector.MetricSet) ([]*Metric, error) {
+	// Подгружаем элементы, созданные правилами обнаружения
+	err := c.refreshItemsIfNeeded(ctx, metrics)
 
-	// Конвертируем метрики в формат Zabbix Sender
-	senderMetrics := c.convertMetricsToSenderData(metrics)
+	return c.convertMetricsToSenderData(metrics), err
+}
 
-	if len(senderMetrics) == 0 {
+// SendData отправляет подготовленные значения в Zabbix через Sender протокол.
+// Значения разбиваются на пакеты по количеству и размеру и отправляются по порядку
+// (или параллельно), ответы сервера объединяются.
+// При частичном отказе возвращает *FailedValuesError вместе с ответом сервера,
+// при недоставленных пакетах - *UnsentValuesError.
+func (c *Client) SendData(ctx context.Context, data []*Metric) (*SenderResponse, error) {
+	c.logger.Debug("Sending metrics to Zabbix via Sender")
+
+	if len(data) == 0 {
 		c.logger.Warn("No metrics to send")
-		return nil
+		return &SenderResponse{Response: "success"}, nil
 	}
 
-	// Создаем пакет и отправляем данные через Zabbix Sender
-	packet := NewPacket(senderMetrics)
-	_, err := c.sender.Send(packet)
-	if err != nil {
-		return fmt.Errorf("failed to send metrics via sender: %w", err)
+	chunks := SplitMetrics(data, c.batch.Size, c.batch.MaxBytes)
+	results := c.sendChunks(ctx, chunks)
+
+	// Объединяем результаты в порядке пакетов
+	merged := &SenderResponse{Response: "success"}
+	var rejected, unsent []*Metric
+	var sendErr error
+	for i, result := range results {
+		var failedErr *FailedValuesError
+		switch {
+		case result.err == nil:
+			merged.Merge(result.resp)
+		case errors.As(result.err, &failedErr):
+			merged.Merge(result.resp)
+			rejected = append(rejected, chunks[i]...)
+		default:
+			unsent = append(unsent, chunks[i]...)
+			if sendErr == nil {
+				sendErr = result.err
+			}
+		}
 	}
 
-	c.logger.Debug("Successfully sent metrics", zap.Int("count", len(senderMetrics)))
-	return nil
+	if len(unsent) > 0 {
+		if merged.Failed > 0 {
+			c.logger.Warn("Zabbix rejected some values", zap.Int("failed", merged.Failed))
+		}
+		return merged, &UnsentValuesError{
+			Err:     fmt.Errorf("failed to send metrics via sender: %w", sendErr),
+			Metrics: unsent,
+		}
+	}
+
+	if merged.Failed > 0 {
+		return merged, &FailedValuesError{Response: merged, Metrics: rejected}
+	}
+
+	c.logger.Debug("Successfully sent metrics",
+		zap.Int("count", len(data)),
+		zap.Int("packets", len(chunks)),
+		zap.Int("processed", merged.Processed),
+		zap.Float64("seconds_spent", merged.SecondsSpent))
+	return merged, nil
+}
+
+// chunkResult результат отправки одного пакета
+type chunkResult struct {
+	resp *SenderResponse
+	err  error
+}
+
+// sendChunks отправляет пакеты последовательно или параллельно
+func (c *Client) sendChunks(ctx context.Context, chunks [][]*Metric) []chunkResult {
+	results := make([]chunkResult, len(chunks))
+
+	if c.batch.Parallelism <= 1 || len(chunks) == 1 {
+		var sendErr error
+		for i, chunk := range chunks {
+			if sendErr == nil {
+				sendErr = ctx.Err()
+			}
+			// После сетевой ошибки остальные пакеты не отправляем, сервер скорее всего недоступен
+			if sendErr != nil {
+				results[i].err = sendErr
+				continue
+			}
+
+			results[i].resp, results[i].err = c.send(ctx, NewPacket(chunk))
+			var failedErr *FailedValuesError
+			if results[i].err != nil && !errors.As(results[i].err, &failedErr) {
+				sendErr = results[i].err
+			}
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, c.batch.Parallelism)
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk []*Metric) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+
+			results[i].resp, results[i].err = c.send(ctx, NewPacket(chunk))
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CreateItems создает отсутствующие элементы данных каталога с указанными ключами.
+// Ключи элементов обнаружения и неизвестные ключи пропускаются.
+// Возвращает количество созданных элементов.
+func (c *Client) CreateItems(ctx context.Context, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if err := c.loadItems(ctx); err != nil {
+		return 0, fmt.Errorf("failed to load items: %w", err)
+	}
+	return c.createMissingItems(ctx, keys...)
+}
+
+// FindRejected определяет значения, отклоненные сервером, делением пакета пополам.
+// Принимает ошибку частичного отказа; принятые при повторной отправке значения
+// дублируются в истории, поэтому метод предназначен для диагностики. Пробные пакеты
+// отправляются только на первый доступный адрес, даже при политике fanout.
+func (c *Client) FindRejected(ctx context.Context, failedErr *FailedValuesError) ([]*Metric, error) {
+	return c.bisect(ctx, failedErr.Metrics, failedErr.Response.Failed)
+}
+
+// bisect рекурсивно ищет отклоненные значения в пакете с известным числом отказов
+func (c *Client) bisect(ctx context.Context, data []*Metric, failed int) ([]*Metric, error) {
+	if failed == 0 || len(data) == 0 {
+		return nil, nil
+	}
+	if failed >= len(data) {
+		return data, nil
+	}
+
+	var rejected []*Metric
+	mid := len(data) / 2
+	for _, half := range [][]*Metric{data[:mid], data[mid:]} {
+		if err := ctx.Err(); err != nil {
+			return rejected, err
+		}
+
+		packet := NewPacket(half)
+		_, err := c.dispatch(ctx, PolicyFailover, func(ctx context.Context, sender *Sender) (*SenderResponse, error) {
+			return sender.SendContext(ctx, packet)
+		})
+		var failedErr *FailedValuesError
+		switch {
+		case err == nil:
+			continue
+		case errors.As(err, &failedErr):
+			found, err := c.bisect(ctx, half, failedErr.Response.Failed)
+			rejected = append(rejected, found...)
+			if err != nil {
+				return rejected, err
+			}
+		default:
+			return rejected, fmt.Errorf("failed to send metrics via sender: %w", err)
+		}
+	}
+
+	return rejected, nil
 }
 
 // convertMetricsToSenderData конвертирует собранные метрики в формат Zabbix Sender
@@ -346,39 +1091,14 @@ func (c *Client) convertMetricsToSenderData(metrics *collector.MetricSet) []*Met
 	var senderMetrics []*Metric
 	timestamp := metrics.Timestamp.Unix()
 
-	// Функция для добавления метрики
-	addMetric := func(key string, value interface{}) {
-		if _, exists := c.items[key]; exists {
-			metric := NewMetric(c.hostName, key, fmt.Sprintf("%v", value), timestamp)
-			senderMetrics = append(senderMetrics, metric)
+	// Отправляем только метрики, для которых существуют элементы данных
+	for _, key := range metrics.Keys() {
+		if _, exists := c.items[key]; !exists {
+			continue
 		}
+		value, _ := metrics.Get(key)
+		senderMetrics = append(senderMetrics, NewMetric(c.hostName, key, fmt.Sprintf("%v", value), timestamp))
 	}
 
-	// CPU метрики
-	addMetric("system.cpu.util[,idle]", metrics.CPU.UsagePercent)
-	addMetric("system.cpu.load[percpu,avg1]", metrics.CPU.LoadAvg1)
-	addMetric("system.cpu.load[percpu,avg5]", metrics.CPU.LoadAvg5)
-	addMetric("system.cpu.load[percpu,avg15]", metrics.CPU.LoadAvg15)
-
-	// Memory метрики
-	addMetric("vm.memory.size[total]", metrics.Memory.TotalBytes)
-	addMetric("vm.memory.size[used]", metrics.Memory.UsedBytes)
-	addMetric("vm.memory.size[available]", metrics.Memory.AvailableBytes)
-	addMetric("vm.memory.util", metrics.Memory.UsagePercent)
-
-	// Disk метрики
-	addMetric("vfs.fs.size[/,total]", metrics.Disk.TotalBytes)
-	addMetric("vfs.fs.size[/,used]", metrics.Disk.UsedBytes)
-	addMetric("vfs.fs.size[/,free]", metrics.Disk.FreeBytes)
-	addMetric("vfs.fs.pused[/]", metrics.Disk.UsagePercent)
-
-	// Network метрики
-	addMetric("net.if.in[all]", metrics.Network.BytesRecv)
-	addMetric("net.if.out[all]", metrics.Network.BytesSent)
-	addMetric("net.if.in[all,packets]", metrics.Network.PacketsRecv)
-	addMetric("net.if.out[all,packets]", metrics.Network.PacketsSent)
-	addMetric("net.if.in[all,errors]", metrics.Network.ErrorsIn)
-	addMetric("net.if.out[all,errors]", metrics.Network.ErrorsOut)
-
 	return senderMetrics
 }
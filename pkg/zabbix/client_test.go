@@ -0,0 +1,122 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// apiCall запрос, полученный заглушкой Zabbix API
+type apiCall struct {
+	Method string
+	Params json.RawMessage
+}
+
+// apiStub сервер Zabbix API, отвечающий результатами обработчиков по имени метода
+type apiStub struct {
+	t        *testing.T
+	handlers map[string]func(params json.RawMessage) interface{}
+
+	mu    sync.Mutex
+	calls []apiCall
+}
+
+func (s *apiStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		ID     int             `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.t.Errorf("stub got invalid request: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, apiCall{Method: request.Method, Params: request.Params})
+	handler, ok := s.handlers[request.Method]
+	s.mu.Unlock()
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+	if ok {
+		response["result"] = handler(request.Params)
+	} else {
+		response["error"] = map[string]interface{}{"code": -32601, "message": "Method not found.", "data": request.Method}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// methodCalls возвращает параметры запросов с указанным методом
+func (s *apiStub) methodCalls(method string) []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []json.RawMessage
+	for _, call := range s.calls {
+		if call.Method == method {
+			result = append(result, call.Params)
+		}
+	}
+	return result
+}
+
+// startAPI запускает заглушку Zabbix API и возвращает клиент хоста web-1 для нее
+func startAPI(t *testing.T, handlers map[string]func(params json.RawMessage) interface{}) (*Client, *apiStub) {
+	t.Helper()
+
+	stub := &apiStub{t: t, handlers: handlers}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, "", "", time.Second, zap.NewNop())
+	client.hostID = "10084"
+	client.hostName = "web-1"
+	return client, stub
+}
+
+func TestCreateItems(t *testing.T) {
+	client, stub := startAPI(t, map[string]func(json.RawMessage) interface{}{
+		"item.get": func(json.RawMessage) interface{} {
+			return []Item{{ItemID: "1", Key: "system.cpu.num"}}
+		},
+		"discoveryrule.get": func(json.RawMessage) interface{} {
+			return []DiscoveryRule{}
+		},
+		"item.create": func(params json.RawMessage) interface{} {
+			var items []ItemCreateParams
+			json.Unmarshal(params, &items)
+			ids := make([]string, len(items))
+			for i := range items {
+				ids[i] = strconv.Itoa(100 + i)
+			}
+			return map[string][]string{"itemids": ids}
+		},
+	})
+
+	// Существующий элемент, элемент каталога, ключ обнаружения и неизвестный ключ
+	keys := []string{"system.cpu.num", "vm.memory.util", "vfs.fs.size[/,total]", "unknown.key"}
+	created, err := client.CreateItems(context.Background(), keys)
+	if err != nil || created != 1 {
+		t.Fatalf("CreateItems = %d, %v, want 1 created", created, err)
+	}
+
+	creates := stub.methodCalls("item.create")
+	if len(creates) != 1 {
+		t.Fatalf("item.create called %d times, want 1", len(creates))
+	}
+	var items []ItemCreateParams
+	json.Unmarshal(creates[0], &items)
+	if len(items) != 1 || items[0].Key != "vm.memory.util" {
+		t.Errorf("created items = %+v, want only vm.memory.util", items)
+	}
+	if id := client.items["vm.memory.util"]; id != "100" {
+		t.Errorf("item id = %q, want 100", id)
+	}
+}
@@ -0,0 +1,63 @@
+package zabbix
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Коды ошибок JSON-RPC, которые возвращает Zabbix API
+const (
+	CodeInvalidParams    = -32602
+	CodeApplicationError = -32500
+)
+
+// Классы ошибок Zabbix API для проверки через errors.Is
+var (
+	ErrSessionExpired   = errors.New("zabbix API session expired")
+	ErrPermissionDenied = errors.New("zabbix API permission denied")
+	ErrInvalidParams    = errors.New("zabbix API invalid params")
+)
+
+// Error реализует интерфейс error
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("zabbix API error: %s (code: %d, data: %s)", e.Message, e.Code, e.Data)
+}
+
+// Is сопоставляет ошибку с классами ErrSessionExpired, ErrPermissionDenied и ErrInvalidParams
+func (e *JSONRPCError) Is(target error) bool {
+	switch target {
+	case ErrSessionExpired:
+		return e.SessionExpired()
+	case ErrPermissionDenied:
+		return e.PermissionDenied()
+	case ErrInvalidParams:
+		return e.InvalidParams()
+	}
+	return false
+}
+
+// SessionExpired проверяет, что сессия истекла или токен недействителен и нужен повторный вход.
+// Текст ошибки отличается в разных версиях Zabbix, код ошибки - нет.
+func (e *JSONRPCError) SessionExpired() bool {
+	if e.Code != CodeInvalidParams && e.Code != CodeApplicationError {
+		return false
+	}
+	data := strings.ToLower(e.Data)
+	return strings.Contains(data, "session terminated") ||
+		strings.Contains(data, "not authorized") ||
+		strings.Contains(data, "not authorised") ||
+		strings.Contains(data, "re-login")
+}
+
+// PermissionDenied проверяет, что у пользователя нет прав на объект или метод
+func (e *JSONRPCError) PermissionDenied() bool {
+	data := strings.ToLower(e.Data)
+	return strings.Contains(data, "no permissions") ||
+		strings.Contains(data, "do not have permission")
+}
+
+// InvalidParams проверяет, что сервер отклонил параметры запроса
+func (e *JSONRPCError) InvalidParams() bool {
+	return e.Code == CodeInvalidParams && !e.SessionExpired()
+}
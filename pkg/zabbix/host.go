@@ -0,0 +1,282 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DefaultAgentPort порт агентского интерфейса по умолчанию
+const DefaultAgentPort = 10050
+
+// Тип и признаки интерфейса хоста
+const (
+	interfaceTypeAgent = "1"
+	interfaceMain      = "1"
+	interfaceUseIP     = "1"
+	interfaceUseDNS    = "0"
+)
+
+// HostConfig содержит настройки автоматической регистрации хоста
+type HostConfig struct {
+	AutoRegister bool      // создавать хост, если он не найден, и синхронизировать теги и шаблоны
+	Groups       []string  // имена групп узлов сети
+	Templates    []string  // технические имена шаблонов
+	VisibleName  string    // видимое имя (пусто - совпадает с именем хоста)
+	Tags         []HostTag // теги хоста
+	Interface    string    // адрес агентского интерфейса host[:port] (пусто - 127.0.0.1:10050)
+}
+
+// ParseHostTags разбирает теги вида tag=value (значение может отсутствовать)
+func ParseHostTags(tags []string) ([]HostTag, error) {
+	result := make([]HostTag, 0, len(tags))
+	for _, tag := range tags {
+		name, value, _ := strings.Cut(tag, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid host tag %q", tag)
+		}
+		result = append(result, HostTag{Tag: name, Value: strings.TrimSpace(value)})
+	}
+	return result, nil
+}
+
+// SetHostConfig задает настройки автоматической регистрации хоста
+func (c *Client) SetHostConfig(cfg HostConfig) {
+	c.hostConfig = cfg
+}
+
+// agentInterface создает агентский интерфейс хоста из адреса host[:port]
+func (c *Client) agentInterface() (HostInterface, error) {
+	address := c.hostConfig.Interface
+	if address == "" {
+		address = "127.0.0.1"
+	}
+
+	host, port := address, strconv.Itoa(DefaultAgentPort)
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		host, port = h, p
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil || host == "" {
+		return HostInterface{}, fmt.Errorf("invalid host interface address %q", address)
+	}
+
+	iface := HostInterface{
+		Type: interfaceTypeAgent,
+		Main: interfaceMain,
+		Port: port,
+	}
+	if net.ParseIP(host) != nil {
+		iface.UseIP, iface.IP = interfaceUseIP, host
+	} else {
+		iface.UseIP, iface.DNS = interfaceUseDNS, host
+	}
+	return iface, nil
+}
+
+// lookupGroups находит идентификаторы групп узлов сети по именам
+func (c *Client) lookupGroups(ctx context.Context, names []string) ([]HostGroupID, error) {
+	resp, err := c.makeRequest(ctx, "hostgroup.get", map[string]interface{}{
+		"output": []string{"groupid", "name"},
+		"filter": map[string][]string{"name": names},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host groups: %w", err)
+	}
+
+	var groups []HostGroup
+	if err := json.Unmarshal(resp.Result, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse host groups: %w", err)
+	}
+
+	ids := make(map[string]string, len(groups))
+	for _, group := range groups {
+		ids[group.Name] = group.GroupID
+	}
+
+	result := make([]HostGroupID, 0, len(names))
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("host group '%s' not found in Zabbix", name)
+		}
+		result = append(result, HostGroupID{GroupID: id})
+	}
+	return result, nil
+}
+
+// lookupTemplates находит идентификаторы шаблонов по техническим именам
+func (c *Client) lookupTemplates(ctx context.Context, names []string) ([]TemplateID, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	resp, err := c.makeRequest(ctx, "template.get", map[string]interface{}{
+		"output": []string{"templateid", "host"},
+		"filter": map[string][]string{"host": names},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	var templates []Template
+	if err := json.Unmarshal(resp.Result, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+
+	ids := make(map[string]string, len(templates))
+	for _, template := range templates {
+		ids[template.Host] = template.TemplateID
+	}
+
+	result := make([]TemplateID, 0, len(names))
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("template '%s' not found in Zabbix", name)
+		}
+		result = append(result, TemplateID{TemplateID: id})
+	}
+	return result, nil
+}
+
+// registerHost создает хост с настроенными группами, шаблонами, тегами и агентским интерфейсом
+func (c *Client) registerHost(ctx context.Context, hostName string) (*Host, error) {
+	c.logger.Info("Registering host in Zabbix",
+		zap.String("host", hostName),
+		zap.Strings("groups", c.hostConfig.Groups),
+		zap.Strings("templates", c.hostConfig.Templates))
+
+	// Без хоста планировать изменения элементов не для чего
+	if c.dryRun() {
+		return nil, fmt.Errorf("host '%s' not found in Zabbix, not registering it in dry run", hostName)
+	}
+
+	groups, err := c.lookupGroups(ctx, c.hostConfig.Groups)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := c.lookupTemplates(ctx, c.hostConfig.Templates)
+	if err != nil {
+		return nil, err
+	}
+	iface, err := c.agentInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	params := HostCreateParams{
+		Host:       hostName,
+		Name:       c.hostConfig.VisibleName,
+		Groups:     groups,
+		Templates:  templates,
+		Tags:       c.hostConfig.Tags,
+		Interfaces: []HostInterface{iface},
+	}
+
+	resp, err := c.makeRequest(ctx, "host.create", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host: %w", err)
+	}
+
+	var result struct {
+		HostIDs []string `json:"hostids"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse host create response: %w", err)
+	}
+	if len(result.HostIDs) == 0 {
+		return nil, fmt.Errorf("host create response contains no host ID")
+	}
+
+	name := c.hostConfig.VisibleName
+	if name == "" {
+		name = hostName
+	}
+	c.logger.Info("Host registered", zap.String("hostID", result.HostIDs[0]))
+	return &Host{HostID: result.HostIDs[0], Host: hostName, Name: name, Status: "0"}, nil
+}
+
+// syncHost добавляет хосту недостающие теги и шаблоны и обновляет видимое имя.
+// Теги и шаблоны, добавленные вручную, сохраняются.
+func (c *Client) syncHost(ctx context.Context, host *Host) error {
+	params := HostUpdateParams{HostID: host.HostID}
+	changed := false
+
+	if name := c.hostConfig.VisibleName; name != "" && name != host.Name {
+		params.Name = name
+		changed = true
+	}
+
+	// Значения настроенных тегов заменяют значения одноименных тегов хоста
+	configured := make(map[string]string, len(c.hostConfig.Tags))
+	for _, tag := range c.hostConfig.Tags {
+		configured[tag.Tag] = tag.Value
+	}
+	tags := make([]HostTag, 0, len(host.Tags)+len(c.hostConfig.Tags))
+	present := make(map[HostTag]bool, len(host.Tags))
+	for _, tag := range host.Tags {
+		if value, ok := configured[tag.Tag]; ok && value != tag.Value {
+			changed = true
+			continue
+		}
+		present[tag] = true
+		tags = append(tags, HostTag{Tag: tag.Tag, Value: tag.Value})
+	}
+	for _, tag := range c.hostConfig.Tags {
+		if !present[tag] {
+			present[tag] = true
+			tags = append(tags, tag)
+			changed = true
+		}
+	}
+
+	templates, err := c.lookupTemplates(ctx, c.hostConfig.Templates)
+	if err != nil {
+		return err
+	}
+	linked := make(map[string]bool, len(host.ParentTemplates))
+	for _, template := range host.ParentTemplates {
+		linked[template.TemplateID] = true
+	}
+	allTemplates := make([]TemplateID, 0, len(host.ParentTemplates)+len(templates))
+	for _, template := range host.ParentTemplates {
+		allTemplates = append(allTemplates, TemplateID{TemplateID: template.TemplateID})
+	}
+	for _, template := range templates {
+		if !linked[template.TemplateID] {
+			linked[template.TemplateID] = true
+			allTemplates = append(allTemplates, template)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if c.dryRun() {
+		c.logger.Info("Host will be updated (dry run)",
+			zap.String("hostID", host.HostID),
+			zap.Int("tags", len(tags)),
+			zap.Int("templates", len(allTemplates)))
+		return nil
+	}
+
+	params.Tags = tags
+	params.Templates = allTemplates
+	if _, err := c.makeRequest(ctx, "host.update", params); err != nil {
+		return fmt.Errorf("failed to update host: %w", err)
+	}
+
+	c.logger.Info("Host tags and templates synchronized",
+		zap.String("hostID", host.HostID),
+		zap.Int("tags", len(tags)),
+		zap.Int("templates", len(allTemplates)))
+	return nil
+}
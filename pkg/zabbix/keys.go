@@ -0,0 +1,72 @@
+package zabbix
+
+import "strings"
+
+// splitKey разбирает ключ элемента данных на имя и параметры.
+// Параметры в кавычках могут содержать запятые и скобки.
+func splitKey(key string) (name string, params []string) {
+	open := strings.IndexByte(key, '[')
+	if open < 0 || !strings.HasSuffix(key, "]") {
+		return key, nil
+	}
+
+	name, body := key[:open], key[open+1:len(key)-1]
+	var param strings.Builder
+	quoted := false
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		switch {
+		case quoted && ch == '\\' && i+1 < len(body) && body[i+1] == '"':
+			param.WriteByte('"')
+			i++
+		case ch == '"':
+			quoted = !quoted
+		case ch == ',' && !quoted:
+			params = append(params, param.String())
+			param.Reset()
+		default:
+			param.WriteByte(ch)
+		}
+	}
+	return name, append(params, param.String())
+}
+
+// matchPrototype проверяет, что ключ получен из ключа прототипа подстановкой макросов LLD
+func matchPrototype(prototype, key string) bool {
+	protoName, protoParams := splitKey(prototype)
+	name, params := splitKey(key)
+	if protoName != name || len(protoParams) != len(params) {
+		return false
+	}
+
+	for i, param := range protoParams {
+		if strings.HasPrefix(param, "{#") && strings.HasSuffix(param, "}") {
+			continue
+		}
+		if param != params[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsKnownKey проверяет, публикуется ли ключ включенными источниками напрямую или через
+// прототип включенного правила обнаружения. Известный ключ может не иметь значения,
+// пока источник не накопил данные (например, скорость до второго цикла сбора).
+func (c *Client) IsKnownKey(key string) bool {
+	if c.isKeyEnabled(key) {
+		return true
+	}
+
+	for _, rule := range GetZabbixDiscoveryRules() {
+		if !c.isKeyEnabled(rule.Key) {
+			continue
+		}
+		for _, prototype := range rule.Prototypes {
+			if matchPrototype(prototype.Key, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// Тег, которым отмечаются элементы данных, созданные утилитой
+const (
+	ManagedTag      = "managed-by"
+	ManagedTagValue = "zabbix_mon"
+)
+
+// Действия с управляемыми элементами данных, которые больше не публикуются
+const (
+	PruneDisable = "disable"
+	PruneDelete  = "delete"
+)
+
+// Состояния элемента данных
+const (
+	itemStatusEnabled  = "0"
+	itemStatusDisabled = "1"
+)
+
+// ReconcileConfig содержит настройки приведения элементов данных к каталогу
+type ReconcileConfig struct {
+	Enabled bool   // обновлять расхождения и обрабатывать устаревшие элементы
+	Prune   string // действие с устаревшими управляемыми элементами (disable, delete)
+	DryRun  bool   // только вывести план изменений, не изменяя хост, элементы и триггеры
+
+	MigrateLegacy bool // удалять элементы прежних версий, ключи которых создает обнаружение
+}
+
+// ItemChange описывает расхождение элемента данных с каталогом
+type ItemChange struct {
+	Key     string   `json:"key"`
+	ItemID  string   `json:"itemid"`
+	Changes []string `json:"changes"` // поле: текущее -> ожидаемое
+}
+
+// ReconcileReport план или результат приведения элементов данных к каталогу
+type ReconcileReport struct {
+	Update  []ItemChange `json:"update,omitempty"`
+	Disable []string     `json:"disable,omitempty"` // ключи
+	Delete  []string     `json:"delete,omitempty"`  // ключи
+}
+
+// Empty проверяет, что изменений нет
+func (r *ReconcileReport) Empty() bool {
+	return len(r.Update) == 0 && len(r.Disable) == 0 && len(r.Delete) == 0
+}
+
+// SetReconcileConfig задает настройки приведения элементов данных к каталогу
+func (c *Client) SetReconcileConfig(cfg ReconcileConfig) {
+	c.reconcile = cfg
+}
+
+// dryRun проверяет, что изменения в Zabbix только выводятся в лог
+func (c *Client) dryRun() bool {
+	return c.reconcile.DryRun
+}
+
+// itemTagsSupported проверяет, поддерживает ли сервер теги элементов данных (Zabbix 5.4+)
+func (c *Client) itemTagsSupported() bool {
+	c.authMutex.RLock()
+	defer c.authMutex.RUnlock()
+	return c.apiVersion.atLeast(5, 4)
+}
+
+// managedTags возвращает теги элемента данных, созданного утилитой
+func (c *Client) managedTags() []ItemTag {
+	if !c.itemTagsSupported() {
+		return nil
+	}
+	return []ItemTag{{Tag: ManagedTag, Value: ManagedTagValue}}
+}
+
+// isManaged проверяет наличие тега управляемого элемента данных
+func isManaged(tags []ItemTag) bool {
+	for _, tag := range tags {
+		if tag.Tag == ManagedTag && tag.Value == ManagedTagValue {
+			return true
+		}
+	}
+	return false
+}
+
+// diffItem сравнивает элемент данных с описанием из каталога. Единицы измерения сравниваются,
+// только если они заданы в каталоге. Состояние сравнивается только у управляемых элементов:
+// элемент, отключенный при удалении ключа из каталога, включается, когда ключ снова публикуется.
+// Элемент без тега, отключенный оператором, остается отключенным.
+func diffItem(item Item, desired ZabbixMetricItem, checkTag bool) []string {
+	var changes []string
+	field := func(name, current, expected string) {
+		if current != expected {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", name, current, expected))
+		}
+	}
+
+	field("name", item.Name, desired.Name)
+	field("description", item.Description, desired.Description)
+	field("value_type", item.ValueType, strconv.Itoa(desired.ValueType))
+	if desired.Units != "" {
+		field("units", item.Units, desired.Units)
+	}
+	if isManaged(item.Tags) {
+		field("status", item.Status, itemStatusEnabled)
+	}
+	if checkTag && !isManaged(item.Tags) {
+		changes = append(changes, fmt.Sprintf("tags: add %s=%s", ManagedTag, ManagedTagValue))
+	}
+	return changes
+}
+
+// reconcileItems приводит элементы данных хоста к каталогу: обновляет расхождения и
+// отключает или удаляет управляемые элементы, которые больше не публикуются.
+// Элементы, унаследованные от шаблонов или созданные обнаружением, не затрагиваются.
+func (c *Client) reconcileItems(ctx context.Context) (*ReconcileReport, error) {
+	tags := c.itemTagsSupported()
+
+	params := ItemGetParams{
+		Output:  []string{"itemid", "name", "key_", "status", "value_type", "description", "units", "flags", "templateid"},
+		HostIDs: []string{c.hostID},
+	}
+	if tags {
+		params.SelectTags = []string{"tag", "value"}
+	}
+
+	resp, err := c.makeRequest(ctx, "item.get", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	var items []Item
+	if err := json.Unmarshal(resp.Result, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse items: %w", err)
+	}
+
+	desired := make(map[string]ZabbixMetricItem)
+	for _, item := range GetZabbixItems() {
+		if c.isKeyEnabled(item.Key) {
+			desired[item.Key] = item
+		}
+	}
+
+	report := &ReconcileReport{}
+	var updates []ItemUpdateParams
+	var enable, disable []ItemStatusParams
+	var remove []string
+
+	for _, item := range items {
+		if item.Flags != "0" || item.TemplateID != "0" {
+			continue
+		}
+
+		if zItem, ok := desired[item.Key]; ok {
+			changes := diffItem(item, zItem, tags)
+			if len(changes) == 0 {
+				continue
+			}
+			report.Update = append(report.Update, ItemChange{Key: item.Key, ItemID: item.ItemID, Changes: changes})
+
+			// Управляемый элемент, отключенный при удалении ключа из каталога, включается снова
+			if isManaged(item.Tags) && item.Status != itemStatusEnabled {
+				enable = append(enable, ItemStatusParams{ItemID: item.ItemID, Status: 0})
+				if len(changes) == 1 {
+					continue
+				}
+			}
+
+			update := ItemUpdateParams{
+				ItemID:      item.ItemID,
+				Name:        zItem.Name,
+				Description: zItem.Description,
+				ValueType:   zItem.ValueType,
+				Units:       zItem.Units,
+			}
+			if tags && !isManaged(item.Tags) {
+				update.Tags = append(item.Tags, c.managedTags()...)
+			}
+			updates = append(updates, update)
+			continue
+		}
+
+		// Элементы без тега созданы вручную и не удаляются
+		if !isManaged(item.Tags) {
+			continue
+		}
+		switch c.reconcile.Prune {
+		case PruneDelete:
+			report.Delete = append(report.Delete, item.Key)
+			remove = append(remove, item.ItemID)
+		case PruneDisable:
+			if item.Status != itemStatusDisabled {
+				report.Disable = append(report.Disable, item.Key)
+				disable = append(disable, ItemStatusParams{ItemID: item.ItemID, Status: 1})
+			}
+		}
+	}
+
+	c.logReconcileReport(report)
+	if c.dryRun() || report.Empty() {
+		return report, nil
+	}
+
+	if len(updates) > 0 {
+		if _, err := c.makeRequest(ctx, "item.update", updates); err != nil {
+			return report, fmt.Errorf("failed to update items: %w", err)
+		}
+	}
+	if len(enable) > 0 {
+		if _, err := c.makeRequest(ctx, "item.update", enable); err != nil {
+			return report, fmt.Errorf("failed to enable items: %w", err)
+		}
+	}
+	if len(disable) > 0 {
+		if _, err := c.makeRequest(ctx, "item.update", disable); err != nil {
+			return report, fmt.Errorf("failed to disable items: %w", err)
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := c.makeRequest(ctx, "item.delete", remove); err != nil {
+			return report, fmt.Errorf("failed to delete items: %w", err)
+		}
+	}
+
+	c.logger.Info("Items reconciled",
+		zap.Int("updated", len(report.Update)),
+		zap.Int("disabled", len(report.Disable)),
+		zap.Int("deleted", len(report.Delete)))
+	return report, nil
+}
+
+// logReconcileReport выводит план приведения элементов данных к каталогу
+func (c *Client) logReconcileReport(report *ReconcileReport) {
+	if report.Empty() {
+		c.logger.Info("Items match the catalogue")
+		return
+	}
+
+	message := "Item reconcile plan"
+	if c.dryRun() {
+		message = "Item reconcile plan (dry run, no changes applied)"
+	}
+	c.logger.Info(message,
+		zap.Int("update", len(report.Update)),
+		zap.Int("disable", len(report.Disable)),
+		zap.Int("delete", len(report.Delete)))
+
+	for _, change := range report.Update {
+		c.logger.Info("Item will be updated",
+			zap.String("key", change.Key),
+			zap.String("itemID", change.ItemID),
+			zap.Strings("changes", change.Changes))
+	}
+	for _, key := range report.Disable {
+		c.logger.Info("Obsolete item will be disabled", zap.String("key", key))
+	}
+	for _, key := range report.Delete {
+		c.logger.Info("Obsolete item will be deleted", zap.String("key", key))
+	}
+}
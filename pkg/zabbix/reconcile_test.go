@@ -0,0 +1,226 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffItem(t *testing.T) {
+	desired := ZabbixMetricItem{Key: "vm.memory.util", Name: "Memory utilization", ValueType: 0, Units: "%"}
+	managed := []ItemTag{{Tag: ManagedTag, Value: ManagedTagValue}}
+
+	tests := []struct {
+		name    string
+		item    Item
+		desired ZabbixMetricItem
+		want    []string
+	}{
+		{
+			name:    "matches",
+			item:    Item{Name: "Memory utilization", ValueType: "0", Units: "%", Status: "0", Tags: managed},
+			desired: desired,
+		},
+		{
+			name:    "managed item disabled by prune",
+			item:    Item{Name: "Memory utilization", ValueType: "0", Units: "%", Status: "1", Tags: managed},
+			desired: desired,
+			want:    []string{"status"},
+		},
+		{
+			name:    "item without tag disabled by operator",
+			item:    Item{Name: "Memory utilization", ValueType: "0", Units: "%", Status: "1"},
+			desired: desired,
+			want:    []string{"tags"},
+		},
+		{
+			name:    "units set by operator are kept when catalogue has none",
+			item:    Item{Name: "CPU count", ValueType: "3", Units: "cores", Status: "0", Tags: managed},
+			desired: ZabbixMetricItem{Key: "system.cpu.num", Name: "CPU count", ValueType: 3},
+		},
+		{
+			name:    "units and value type differ",
+			item:    Item{Name: "Memory utilization", ValueType: "3", Units: "", Status: "0", Tags: managed},
+			desired: desired,
+			want:    []string{"value_type", "units"},
+		},
+		{
+			name:    "missing tag",
+			item:    Item{Name: "Memory utilization", ValueType: "0", Units: "%", Status: "0"},
+			desired: desired,
+			want:    []string{"tags"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diffItem(tt.item, tt.desired, true)
+			if len(changes) != len(tt.want) {
+				t.Fatalf("changes = %q, want fields %q", changes, tt.want)
+			}
+			for i, field := range tt.want {
+				if !strings.HasPrefix(changes[i], field+":") {
+					t.Errorf("change %d = %q, want field %s", i, changes[i], field)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileItemsEnablesManagedItems(t *testing.T) {
+	managed := []ItemTag{{Tag: ManagedTag, Value: ManagedTagValue}}
+	catalogue := make(map[string]ZabbixMetricItem)
+	for _, item := range GetZabbixItems() {
+		catalogue[item.Key] = item
+	}
+	// item возвращает отключенный элемент, совпадающий с каталогом
+	item := func(id, key string, tags []ItemTag) Item {
+		zItem := catalogue[key]
+		return Item{ItemID: id, Key: key, Name: zItem.Name, Description: zItem.Description,
+			ValueType: strconv.Itoa(zItem.ValueType), Units: zItem.Units, Status: "1", Flags: "0", TemplateID: "0", Tags: tags}
+	}
+
+	client, stub := startAPI(t, map[string]func(json.RawMessage) interface{}{
+		"item.get": func(json.RawMessage) interface{} {
+			return []Item{
+				// Отключен при удалении ключа из каталога, ключ снова публикуется
+				item("1", "vm.memory.util", managed),
+				// Отключен оператором, тега нет
+				item("2", "system.cpu.num", nil),
+			}
+		},
+		"item.update": func(json.RawMessage) interface{} {
+			return map[string][]string{"itemids": {"1"}}
+		},
+	})
+	client.apiVersion = apiVersion{major: 6, minor: 0}
+	client.SetEnabledKeys([]string{"vm.memory.util", "system.cpu.num"})
+	client.SetReconcileConfig(ReconcileConfig{Enabled: true, Prune: PruneDisable, DryRun: true})
+
+	report, err := client.reconcileItems(context.Background())
+	if err != nil {
+		t.Fatalf("reconcileItems: %v", err)
+	}
+	if len(report.Update) == 0 || report.Update[0].Key != "vm.memory.util" ||
+		strings.Join(report.Update[0].Changes, ";") != `status: "1" -> "0"` {
+		t.Fatalf("dry run report = %+v, want status change for vm.memory.util", report.Update)
+	}
+	if updates := stub.methodCalls("item.update"); len(updates) != 0 {
+		t.Fatalf("dry run called item.update %d times", len(updates))
+	}
+
+	client.SetReconcileConfig(ReconcileConfig{Enabled: true, Prune: PruneDisable})
+	if _, err := client.reconcileItems(context.Background()); err != nil {
+		t.Fatalf("reconcileItems: %v", err)
+	}
+
+	// Включается только управляемый элемент, элемент оператора получает только тег
+	var enabled bool
+	for _, params := range stub.methodCalls("item.update") {
+		var statuses []ItemStatusParams
+		json.Unmarshal(params, &statuses)
+		for _, status := range statuses {
+			if status.ItemID == "2" && strings.Contains(string(params), `"status"`) {
+				t.Errorf("item disabled by operator was enabled: %s", params)
+			}
+			if status.ItemID == "1" && status.Status == 0 && strings.Contains(string(params), `"status":0`) {
+				enabled = true
+			}
+		}
+	}
+	if !enabled {
+		t.Errorf("managed item was not enabled")
+	}
+}
+
+func TestInitializeDryRun(t *testing.T) {
+	client, stub := startAPI(t, map[string]func(json.RawMessage) interface{}{
+		"apiinfo.version": func(json.RawMessage) interface{} { return "6.0.0" },
+		"user.login":      func(json.RawMessage) interface{} { return "token" },
+		"host.get": func(json.RawMessage) interface{} {
+			return []Host{{HostID: "10084", Host: "web-1", Name: "web-1", Status: "0"}}
+		},
+		"item.get": func(json.RawMessage) interface{} {
+			return []Item{{ItemID: "1", Key: "vm.memory.util", Name: "Old name", ValueType: "0", Units: "%", Status: "1", Flags: "0", TemplateID: "0"}}
+		},
+		"discoveryrule.get": func(json.RawMessage) interface{} { return []DiscoveryRule{} },
+		"trigger.get":       func(json.RawMessage) interface{} { return []Trigger{} },
+	})
+	client.SetEnabledKeys([]string{"vm.memory.util", "vm.memory.size[total]", "vfs.fs.discovery"})
+	client.SetReconcileConfig(ReconcileConfig{Enabled: true, Prune: PruneDelete, DryRun: true})
+	client.SetTriggers([]ZabbixTrigger{
+		{Name: "High memory utilization", Expression: "min(/{HOST}/vm.memory.util,5m)>{THRESHOLD}", Threshold: "90", Builtin: true},
+	})
+	t.Cleanup(client.Close)
+
+	if err := client.Initialize(context.Background(), "web-1"); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	for _, call := range stub.calls {
+		if !strings.HasSuffix(call.Method, ".get") && call.Method != "apiinfo.version" && call.Method != "user.login" {
+			t.Errorf("dry run called %s", call.Method)
+		}
+	}
+}
+
+func TestMigrateLegacyItems(t *testing.T) {
+	client, stub := startAPI(t, map[string]func(json.RawMessage) interface{}{
+		"item.get": func(json.RawMessage) interface{} {
+			return []Item{
+				{ItemID: "1", Key: "vfs.fs.size[/,total]", Flags: "0", TemplateID: "0"},
+				{ItemID: "2", Key: "vfs.fs.pused[/]", Flags: "0", TemplateID: "0"},
+				{ItemID: "3", Key: "vfs.fs.size[/,free]", Flags: "0", TemplateID: "500"},
+				{ItemID: "4", Key: "vfs.fs.size[/,used]", Flags: "4", TemplateID: "0"},
+				{ItemID: "5", Key: "system.cpu.num", Flags: "0", TemplateID: "0"},
+			}
+		},
+		"item.delete": func(params json.RawMessage) interface{} {
+			var ids []string
+			json.Unmarshal(params, &ids)
+			return map[string][]string{"itemids": ids}
+		},
+	})
+	ctx := context.Background()
+
+	if err := client.migrateLegacyItems(ctx, "net.if.discovery"); err != nil {
+		t.Fatalf("migrateLegacyItems: %v", err)
+	}
+	if len(stub.calls) != 0 {
+		t.Errorf("rule without legacy items made %d requests", len(stub.calls))
+	}
+
+	// Без флага миграции элементы только перечисляются в предупреждении
+	if err := client.migrateLegacyItems(ctx, "vfs.fs.discovery"); err != nil {
+		t.Fatalf("migrateLegacyItems: %v", err)
+	}
+	if deletes := stub.methodCalls("item.delete"); len(deletes) != 0 {
+		t.Fatalf("item.delete called %d times without migration", len(deletes))
+	}
+
+	// В режиме проверки удаление только выводится в лог
+	client.SetReconcileConfig(ReconcileConfig{MigrateLegacy: true, DryRun: true})
+	if err := client.migrateLegacyItems(ctx, "vfs.fs.discovery"); err != nil {
+		t.Fatalf("migrateLegacyItems: %v", err)
+	}
+	if deletes := stub.methodCalls("item.delete"); len(deletes) != 0 {
+		t.Fatalf("item.delete called %d times in dry run", len(deletes))
+	}
+
+	// Удаляются только обычные элементы хоста, не шаблонов и не обнаружения
+	client.SetReconcileConfig(ReconcileConfig{MigrateLegacy: true})
+	if err := client.migrateLegacyItems(ctx, "vfs.fs.discovery"); err != nil {
+		t.Fatalf("migrateLegacyItems: %v", err)
+	}
+	deletes := stub.methodCalls("item.delete")
+	if len(deletes) != 1 {
+		t.Fatalf("item.delete called %d times, want 1", len(deletes))
+	}
+	var ids []string
+	json.Unmarshal(deletes[0], &ids)
+	if strings.Join(ids, ",") != "1,2" {
+		t.Errorf("deleted items = %v, want [1 2]", ids)
+	}
+}
@@ -2,11 +2,22 @@
 package zabbix
 
 import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -45,6 +56,43 @@ func NewPacket(data []*Metric, clock ...int64) *Packet {
 	return p
 }
 
+// packetOverhead approximate size of packet fields besides data.
+const packetOverhead = 64
+
+// SplitMetrics split metrics into chunks limited by count and encoded size in bytes.
+// Zero limit means no limit. Metric larger than maxBytes is placed into its own chunk.
+func SplitMetrics(data []*Metric, maxCount, maxBytes int) [][]*Metric {
+	return splitChunks(data, maxCount, maxBytes)
+}
+
+// splitChunks split values into chunks limited by count and JSON encoded size in bytes.
+func splitChunks[T any](data []T, maxCount, maxBytes int) [][]T {
+	var chunks [][]T
+	var chunk []T
+	size := packetOverhead
+
+	for _, value := range data {
+		encoded, _ := json.Marshal(value)
+		valueSize := len(encoded) + 1 // comma separator
+
+		if len(chunk) > 0 && ((maxCount > 0 && len(chunk) >= maxCount) ||
+			(maxBytes > 0 && size+valueSize > maxBytes)) {
+			chunks = append(chunks, chunk)
+			chunk = nil
+			size = packetOverhead
+		}
+
+		chunk = append(chunk, value)
+		size += valueSize
+	}
+
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
 // DataLen Packet class method, return 8 bytes with packet length in little endian order.
 func (p *Packet) DataLen() []byte {
 	dataLen := make([]byte, 8)
@@ -53,10 +101,254 @@ func (p *Packet) DataLen() []byte {
 	return dataLen
 }
 
+// Protocol header flags.
+const (
+	flagZabbix     = 0x01
+	flagCompressed = 0x02
+	flagLarge      = 0x04
+)
+
+// MaxFrameSize maximum accepted payload size, same as Zabbix ZBX_MAX_RECV_LARGE_DATA_SIZE.
+const MaxFrameSize = 1 << 30
+
+// EncodeFrame wrap payload into zabbix protocol header, compressing it with zlib if requested.
+// Large packet format (64-bit lengths) is used when lengths do not fit into 32 bits.
+func EncodeFrame(payload []byte, compress bool) ([]byte, error) {
+	flags := byte(flagZabbix)
+	data := payload
+
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, fmt.Errorf("failed to compress data: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress data: %w", err)
+		}
+		flags |= flagCompressed
+		data = buf.Bytes()
+	}
+
+	// reserved field holds uncompressed size for compressed packets
+	dataLen, reserved := uint64(len(data)), uint64(0)
+	if compress {
+		reserved = uint64(len(payload))
+	}
+
+	var header []byte
+	if dataLen > math.MaxUint32 || reserved > math.MaxUint32 {
+		flags |= flagLarge
+		header = make([]byte, 5+16)
+		binary.LittleEndian.PutUint64(header[5:], dataLen)
+		binary.LittleEndian.PutUint64(header[13:], reserved)
+	} else {
+		header = make([]byte, 5+8)
+		binary.LittleEndian.PutUint32(header[5:], uint32(dataLen))
+		binary.LittleEndian.PutUint32(header[9:], uint32(reserved))
+	}
+	copy(header, "ZBXD")
+	header[4] = flags
+
+	return append(header, data...), nil
+}
+
+// ReadFrame read one zabbix protocol packet, reading exactly the declared length,
+// and return decompressed payload.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	return ReadFrameLimit(r, MaxFrameSize)
+}
+
+// ReadFrameLimit read one zabbix protocol packet like ReadFrame, rejecting packets
+// whose data or uncompressed size exceeds limit before allocating memory for them.
+func ReadFrameLimit(r io.Reader, limit uint64) ([]byte, error) {
+	prefix := make([]byte, 5)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(prefix[:4]) != "ZBXD" {
+		return nil, fmt.Errorf("invalid protocol signature %q", prefix[:4])
+	}
+	flags := prefix[4]
+	if flags&flagZabbix == 0 {
+		return nil, fmt.Errorf("unsupported protocol flags 0x%02x", flags)
+	}
+
+	var dataLen, reserved uint64
+	if flags&flagLarge != 0 {
+		lengths := make([]byte, 16)
+		if _, err := io.ReadFull(r, lengths); err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		dataLen = binary.LittleEndian.Uint64(lengths)
+		reserved = binary.LittleEndian.Uint64(lengths[8:])
+	} else {
+		lengths := make([]byte, 8)
+		if _, err := io.ReadFull(r, lengths); err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		dataLen = uint64(binary.LittleEndian.Uint32(lengths))
+		reserved = uint64(binary.LittleEndian.Uint32(lengths[4:]))
+	}
+
+	if dataLen > limit {
+		return nil, fmt.Errorf("packet size %d exceeds limit %d", dataLen, limit)
+	}
+
+	data := make([]byte, dataLen)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+
+	if flags&flagCompressed == 0 {
+		return data, nil
+	}
+
+	if reserved > limit {
+		return nil, fmt.Errorf("uncompressed size %d exceeds limit %d", reserved, limit)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+	defer zr.Close()
+
+	payload := make([]byte, reserved)
+	if _, err := io.ReadFull(zr, payload); err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+	// data must not be longer than declared
+	if n, _ := zr.Read(make([]byte, 1)); n > 0 {
+		return nil, errors.New("decompressed data exceeds declared size")
+	}
+
+	return payload, nil
+}
+
+// SenderResponse class, decoded trapper reply.
+type SenderResponse struct {
+	Response     string  `json:"response"`
+	Info         string  `json:"info"`
+	Processed    int     `json:"-"`
+	Failed       int     `json:"-"`
+	Total        int     `json:"-"`
+	SecondsSpent float64 `json:"-"`
+}
+
+// Info format: "processed: 18; failed: 0; total: 18; seconds spent: 0.000055"
+var responseInfoRe = regexp.MustCompile(`processed:\s*(\d+);\s*failed:\s*(\d+);\s*total:\s*(\d+);\s*seconds spent:\s*([0-9.]+)`)
+
+// ParseResponse decode trapper reply with or without ZBXD header.
+func ParseResponse(data []byte) (*SenderResponse, error) {
+	if bytes.HasPrefix(data, []byte("ZBXD")) {
+		payload, err := ReadFrame(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data = payload
+	}
+
+	resp := &SenderResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if match := responseInfoRe.FindStringSubmatch(resp.Info); match != nil {
+		resp.Processed, _ = strconv.Atoi(match[1])
+		resp.Failed, _ = strconv.Atoi(match[2])
+		resp.Total, _ = strconv.Atoi(match[3])
+		resp.SecondsSpent, _ = strconv.ParseFloat(match[4], 64)
+	}
+
+	return resp, nil
+}
+
+// Merge SenderResponse method, add counters of another response.
+func (r *SenderResponse) Merge(other *SenderResponse) {
+	r.Processed += other.Processed
+	r.Failed += other.Failed
+	r.Total += other.Total
+	r.SecondsSpent += other.SecondsSpent
+	r.Response = other.Response
+	r.Info = fmt.Sprintf("processed: %d; failed: %d; total: %d; seconds spent: %f",
+		r.Processed, r.Failed, r.Total, r.SecondsSpent)
+}
+
+// FailedValuesError is returned when trapper rejected part of the values.
+type FailedValuesError struct {
+	Response *SenderResponse
+	Metrics  []*Metric // values of the rejected packet
+}
+
+func (e *FailedValuesError) Error() string {
+	return fmt.Sprintf("zabbix rejected %d of %d values", e.Response.Failed, e.Response.Total)
+}
+
+// UnsentValuesError is returned when some packets were not delivered to trapper.
+type UnsentValuesError struct {
+	Err     error
+	Metrics []*Metric // values of the packets that were not delivered
+}
+
+func (e *UnsentValuesError) Error() string {
+	return fmt.Sprintf("%d values were not sent: %v", len(e.Metrics), e.Err)
+}
+
+func (e *UnsentValuesError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultPort default zabbix trapper port.
+const DefaultPort = 10051
+
+// ParseAddress split trapper address "host[:port]" into host and port.
+// Port defaults to DefaultPort, IPv6 address with port must be enclosed in brackets.
+func ParseAddress(addr string) (host string, port int, err error) {
+	host, portStr, splitErr := net.SplitHostPort(addr)
+	if splitErr != nil {
+		// address without port
+		host, port = strings.Trim(addr, "[]"), DefaultPort
+	} else if port, err = strconv.Atoi(portStr); err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port in server address %q", addr)
+	}
+
+	if host == "" || strings.ContainsAny(host, " /") {
+		return "", 0, fmt.Errorf("invalid server address %q", addr)
+	}
+
+	return host, port, nil
+}
+
+// Default sender timeouts.
+const (
+	DefaultDialTimeout  = 5 * time.Second
+	DefaultReadTimeout  = 15 * time.Second
+	DefaultWriteTimeout = 15 * time.Second
+)
+
+// poolIdleTimeout idle pooled connection older than this is closed instead of reused.
+const poolIdleTimeout = 30 * time.Second
+
+// idleConn pooled connection.
+type idleConn struct {
+	conn  net.Conn
+	since time.Time
+}
+
 // Sender class.
 type Sender struct {
-	Host string
-	Port int
+	Host     string
+	Port     int
+	Compress bool        // compress packets with zlib
+	TLS      *tls.Config // encrypt connection, nil means unencrypted
+
+	DialTimeout  time.Duration // 0 means DefaultDialTimeout
+	ReadTimeout  time.Duration // 0 means DefaultReadTimeout
+	WriteTimeout time.Duration // 0 means DefaultWriteTimeout
+	PoolSize     int           // idle keep-alive connections to keep, 0 disables pooling
+
+	mu   sync.Mutex
+	idle []idleConn
 }
 
 // Sender class constructor.
@@ -65,106 +357,202 @@ func NewSender(host string, port int) *Sender {
 	return s
 }
 
-// Method Sender class, return zabbix header.
-func (s *Sender) getHeader() []byte {
-	return []byte("ZBXD\x01")
+// Address Sender class method, return trapper address in host:port format.
+func (s *Sender) Address() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
 }
 
-// Method Sender class, resolve uri by name:port.
-func (s *Sender) getTCPAddr() (iaddr *net.TCPAddr, err error) {
-	// format: hostname:port
-	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
-
-	// Resolve hostname:port to ip:port
-	iaddr, err = net.ResolveTCPAddr("tcp", addr)
-	if err != nil {
-		err = fmt.Errorf("Connection failed: %s", err.Error())
-		return
+// Method Sender class, return timeout or its default value.
+func timeoutOrDefault(timeout, def time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
 	}
-
-	return
+	return def
 }
 
-// Method Sender class, make connection to uri.
-func (s *Sender) connect() (conn *net.TCPConn, err error) {
-
-	type DialResp struct {
-		Conn  *net.TCPConn
-		Error error
+// Method Sender class, make connection to uri. Hostname is resolved on every call.
+func (s *Sender) connect(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeoutOrDefault(s.DialTimeout, DefaultDialTimeout)}
+	if s.PoolSize > 0 {
+		dialer.KeepAlive = poolIdleTimeout
 	}
 
-	// Open connection to zabbix host
-	iaddr, err := s.getTCPAddr()
+	conn, err := dialer.DialContext(ctx, "tcp", s.Address())
 	if err != nil {
-		return
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+
+	if s.TLS == nil {
+		return conn, nil
 	}
 
-	// dial tcp and handle timeouts
-	ch := make(chan DialResp)
+	// Wrap connection with TLS and complete handshake within dial timeout
+	tlsConn := tls.Client(conn, s.TLS)
+	handshakeCtx, cancel := context.WithTimeout(ctx, dialer.Timeout)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
 
-	go func() {
-		conn, err = net.DialTCP("tcp", nil, iaddr)
-		ch <- DialResp{Conn: conn, Error: err}
-	}()
+	return tlsConn, nil
+}
 
-	select {
-	case <-time.After(5 * time.Second):
-		err = fmt.Errorf("Connection Timeout")
-	case resp := <-ch:
-		if resp.Error != nil {
-			err = resp.Error
-			break
+// Method Sender class, take idle connection from pool or make a new one.
+func (s *Sender) getConn(ctx context.Context) (conn net.Conn, reused bool, err error) {
+	s.mu.Lock()
+	for len(s.idle) > 0 {
+		last := s.idle[len(s.idle)-1]
+		s.idle = s.idle[:len(s.idle)-1]
+		if time.Since(last.since) < poolIdleTimeout {
+			s.mu.Unlock()
+			return last.conn, true, nil
 		}
+		last.conn.Close()
+	}
+	s.mu.Unlock()
+
+	conn, err = s.connect(ctx)
+	return conn, false, err
+}
+
+// Method Sender class, return connection to pool or close it.
+func (s *Sender) putConn(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		conn = resp.Conn
+	if len(s.idle) >= s.PoolSize {
+		conn.Close()
+		return
 	}
+	s.idle = append(s.idle, idleConn{conn: conn, since: time.Now()})
+}
 
-	return
+// Close Sender class method, close idle pooled connections.
+func (s *Sender) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, idle := range s.idle {
+		idle.conn.Close()
+	}
+	s.idle = nil
 }
 
 // Method Sender class, read data from connection.
-func (s *Sender) read(conn *net.TCPConn) (res []byte, err error) {
-	res = make([]byte, 1024)
-	res, err = io.ReadAll(conn)
+func (s *Sender) read(conn net.Conn) (res []byte, err error) {
+	res, err = ReadFrame(conn)
 	if err != nil {
-		err = fmt.Errorf("Error whule receiving the data: %s", err.Error())
+		err = fmt.Errorf("error while receiving the data: %w", err)
 		return
 	}
 
 	return
 }
 
-// Method Sender class, send packet to zabbix.
-func (s *Sender) Send(packet *Packet) (res []byte, err error) {
-	conn, err := s.connect()
+// Method Sender class, write request and read reply with deadlines.
+func (s *Sender) exchange(conn net.Conn, buffer []byte) ([]byte, error) {
+	conn.SetWriteDeadline(time.Now().Add(timeoutOrDefault(s.WriteTimeout, DefaultWriteTimeout)))
+	if _, err := conn.Write(buffer); err != nil {
+		return nil, fmt.Errorf("error while sending the data: %w", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(timeoutOrDefault(s.ReadTimeout, DefaultReadTimeout)))
+	res, err := s.read(conn)
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer conn.Close()
 
-	dataPacket, _ := json.Marshal(packet)
+	conn.SetDeadline(time.Time{})
+	return res, nil
+}
 
-	/*
-	   fmt.Printf("HEADER: % x (%s)\n", s.getHeader(), s.getHeader())
-	   fmt.Printf("DATALEN: % x, %d byte\n", packet.DataLen(), len(packet.DataLen()))
-	   fmt.Printf("BODY: %s\n", string(dataPacket))
-	*/
+// isClosedByPeer report whether pooled connection was closed by server before reply.
+func isClosedByPeer(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
 
-	// Fill buffer
-	buffer := append(s.getHeader(), packet.DataLen()...)
-	buffer = append(buffer, dataPacket...)
+// Method Sender class, send packet to zabbix and decode the reply.
+// Returns *FailedValuesError together with the response if some values were rejected.
+func (s *Sender) Send(packet *Packet) (resp *SenderResponse, err error) {
+	return s.SendContext(context.Background(), packet)
+}
+
+// SendContext Sender class method, send packet to zabbix and decode the reply.
+// Cancelling ctx aborts dial and I/O. Returns *FailedValuesError together with
+// the response if some values were rejected.
+func (s *Sender) SendContext(ctx context.Context, packet *Packet) (resp *SenderResponse, err error) {
+	res, err := s.request(ctx, packet)
+	if err != nil {
+		return
+	}
 
-	// Sent packet to zabbix
-	_, err = conn.Write(buffer)
+	resp, err = ParseResponse(res)
 	if err != nil {
-		err = fmt.Errorf("Error while sending the data: %s", err.Error())
 		return
 	}
 
-	res, err = s.read(conn)
+	if resp.Response != "success" {
+		err = fmt.Errorf("zabbix server returned %q: %s", resp.Response, resp.Info)
+		return
+	}
+
+	if resp.Failed > 0 {
+		err = &FailedValuesError{Response: resp, Metrics: packet.Data}
+	}
 
-	/*
-	   fmt.Printf("RESPONSE: %s\n", string(res))
-	*/
 	return
 }
+
+// Method Sender class, encode request as JSON, send it and return reply payload.
+func (s *Sender) request(ctx context.Context, request interface{}) ([]byte, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	// Fill buffer
+	buffer, err := EncodeFrame(data, s.Compress)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.roundTrip(ctx, buffer)
+}
+
+// Method Sender class, send encoded packet over pooled or new connection.
+func (s *Sender) roundTrip(ctx context.Context, buffer []byte) ([]byte, error) {
+	for {
+		conn, reused, err := s.getConn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Unblock I/O when context is cancelled
+		stop := context.AfterFunc(ctx, func() {
+			conn.SetDeadline(time.Now())
+		})
+
+		res, err := s.exchange(conn, buffer)
+		if !stop() {
+			conn.Close()
+			return nil, ctx.Err()
+		}
+
+		if err != nil {
+			conn.Close()
+			// Server may close idle pooled connection, retry with a new one
+			if reused && isClosedByPeer(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		if s.PoolSize > 0 {
+			s.putConn(conn)
+		} else {
+			conn.Close()
+		}
+		return res, nil
+	}
+}
@@ -0,0 +1,453 @@
+package zabbix
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// serveTrapper обслуживает соединения ln как trapper: читает кадр запроса и отвечает
+// результатом reply. Останавливается при завершении теста.
+func serveTrapper(t *testing.T, ln net.Listener, reply func(request []byte) []byte) {
+	t.Helper()
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					request, err := ReadFrame(conn)
+					if err != nil {
+						return
+					}
+					frame, err := EncodeFrame(reply(request), false)
+					if err != nil {
+						return
+					}
+					if _, err := conn.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+}
+
+// startTrapper запускает trapper на локальном TCP порту и возвращает Sender для него
+func startTrapper(t *testing.T, reply func(request []byte) []byte) *Sender {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	serveTrapper(t, ln, reply)
+
+	addr := ln.Addr().(*net.TCPAddr)
+	sender := NewSender(addr.IP.String(), addr.Port)
+	sender.DialTimeout = time.Second
+	sender.ReadTimeout = time.Second
+	sender.WriteTimeout = time.Second
+	t.Cleanup(sender.Close)
+	return sender
+}
+
+// senderReply формирует ответ trapper на пакет sender data
+func senderReply(processed, failed int) []byte {
+	info := fmt.Sprintf("processed: %d; failed: %d; total: %d; seconds spent: 0.000055",
+		processed, failed, processed+failed)
+	data, _ := json.Marshal(map[string]string{"response": "success", "info": info})
+	return data
+}
+
+// largeFrame кодирует кадр с 64-битными длинами (флаг 0x04)
+func largeFrame(payload []byte, compress bool) []byte {
+	flags := byte(flagZabbix | flagLarge)
+	data, reserved := payload, uint64(0)
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(payload)
+		w.Close()
+		flags |= flagCompressed
+		data, reserved = buf.Bytes(), uint64(len(payload))
+	}
+
+	header := make([]byte, 5+16)
+	copy(header, "ZBXD")
+	header[4] = flags
+	binary.LittleEndian.PutUint64(header[5:], uint64(len(data)))
+	binary.LittleEndian.PutUint64(header[13:], reserved)
+	return append(header, data...)
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	payload := []byte(`{"request":"sender data","data":[{"host":"h","key":"k","value":"` +
+		strings.Repeat("x", 4096) + `","clock":1}]}`)
+
+	tests := []struct {
+		name     string
+		compress bool
+		flags    byte
+	}{
+		{name: "uncompressed", compress: false, flags: flagZabbix},
+		{name: "zlib", compress: true, flags: flagZabbix | flagCompressed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := EncodeFrame(payload, tt.compress)
+			if err != nil {
+				t.Fatalf("EncodeFrame: %v", err)
+			}
+			if string(frame[:4]) != "ZBXD" || frame[4] != tt.flags {
+				t.Fatalf("header = %q 0x%02x, want ZBXD 0x%02x", frame[:4], frame[4], tt.flags)
+			}
+			if tt.compress {
+				if reserved := binary.LittleEndian.Uint32(frame[9:]); int(reserved) != len(payload) {
+					t.Errorf("reserved = %d, want uncompressed size %d", reserved, len(payload))
+				}
+				if len(frame) >= len(payload) {
+					t.Errorf("compressed frame is not smaller than payload: %d >= %d", len(frame), len(payload))
+				}
+			}
+
+			got, err := ReadFrame(bytes.NewReader(frame))
+			if err != nil {
+				t.Fatalf("ReadFrame: %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Errorf("payload mismatch after round trip")
+			}
+		})
+	}
+}
+
+func TestReadFrameLarge(t *testing.T) {
+	payload := []byte(`{"response":"success"}`)
+
+	for _, compress := range []bool{false, true} {
+		t.Run("compress="+strconv.FormatBool(compress), func(t *testing.T) {
+			got, err := ReadFrame(bytes.NewReader(largeFrame(payload, compress)))
+			if err != nil {
+				t.Fatalf("ReadFrame: %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Errorf("payload = %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+func TestReadFrameInvalid(t *testing.T) {
+	frame, err := EncodeFrame([]byte(`{"response":"success"}`), false)
+	if err != nil {
+		t.Fatalf("EncodeFrame: %v", err)
+	}
+	compressed, err := EncodeFrame([]byte(strings.Repeat("a", 100)), true)
+	if err != nil {
+		t.Fatalf("EncodeFrame: %v", err)
+	}
+
+	oversized := make([]byte, 13)
+	copy(oversized, "ZBXD")
+	oversized[4] = flagZabbix
+	binary.LittleEndian.PutUint32(oversized[5:], MaxFrameSize+1)
+
+	oversizedLarge := make([]byte, 21)
+	copy(oversizedLarge, "ZBXD")
+	oversizedLarge[4] = flagZabbix | flagLarge
+	binary.LittleEndian.PutUint64(oversizedLarge[5:], 1<<40)
+
+	// Сжатые данные длиннее объявленного размера
+	understated := append([]byte(nil), compressed...)
+	binary.LittleEndian.PutUint32(understated[9:], 10)
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{name: "empty", frame: nil},
+		{name: "truncated header", frame: frame[:7]},
+		{name: "truncated data", frame: frame[:len(frame)-3]},
+		{name: "truncated large header", frame: largeFrame([]byte("{}"), false)[:15]},
+		{name: "bad signature", frame: append([]byte("ZBXE"), frame[4:]...)},
+		{name: "no protocol flag", frame: append([]byte{'Z', 'B', 'X', 'D', 0}, frame[5:]...)},
+		{name: "oversized", frame: oversized},
+		{name: "oversized large", frame: oversizedLarge},
+		{name: "truncated compressed", frame: compressed[:len(compressed)-4]},
+		{name: "understated uncompressed size", frame: understated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadFrame(bytes.NewReader(tt.frame)); err == nil {
+				t.Errorf("ReadFrame accepted invalid frame")
+			}
+		})
+	}
+}
+
+func TestReadFrameLimit(t *testing.T) {
+	payload := []byte(strings.Repeat("a", 100))
+	for _, compress := range []bool{false, true} {
+		frame, err := EncodeFrame(payload, compress)
+		if err != nil {
+			t.Fatalf("EncodeFrame: %v", err)
+		}
+
+		if got, err := ReadFrameLimit(bytes.NewReader(frame), 100); err != nil || !bytes.Equal(got, payload) {
+			t.Errorf("compress=%v: ReadFrameLimit = %q, %v", compress, got, err)
+		}
+		if _, err := ReadFrameLimit(bytes.NewReader(frame), 99); err == nil || !strings.Contains(err.Error(), "exceeds limit 99") {
+			t.Errorf("compress=%v: error = %v, want limit error", compress, err)
+		}
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	payload := senderReply(5, 2)
+	frame, err := EncodeFrame(payload, false)
+	if err != nil {
+		t.Fatalf("EncodeFrame: %v", err)
+	}
+
+	for name, data := range map[string][]byte{"framed": frame, "bare": payload} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := ParseResponse(data)
+			if err != nil {
+				t.Fatalf("ParseResponse: %v", err)
+			}
+			if resp.Response != "success" || resp.Processed != 5 || resp.Failed != 2 || resp.Total != 7 {
+				t.Errorf("response = %+v, want processed 5, failed 2, total 7", resp)
+			}
+		})
+	}
+
+	if _, err := ParseResponse([]byte("not json")); err == nil {
+		t.Errorf("ParseResponse accepted invalid JSON")
+	}
+}
+
+func TestSplitMetrics(t *testing.T) {
+	var data []*Metric
+	for i := 0; i < 10; i++ {
+		data = append(data, NewMetric("h", "key"+strconv.Itoa(i), strings.Repeat("v", 50), 1))
+	}
+	encoded, _ := json.Marshal(data[0])
+	metricSize := len(encoded) + 1
+
+	tests := []struct {
+		name     string
+		maxCount int
+		maxBytes int
+		want     []int
+	}{
+		{name: "no limits", want: []int{10}},
+		{name: "count", maxCount: 4, want: []int{4, 4, 2}},
+		{name: "bytes", maxBytes: packetOverhead + 3*metricSize, want: []int{3, 3, 3, 1}},
+		{name: "count and bytes", maxCount: 2, maxBytes: packetOverhead + 3*metricSize, want: []int{2, 2, 2, 2, 2}},
+		{name: "metric larger than limit", maxBytes: 10, want: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := SplitMetrics(data, tt.maxCount, tt.maxBytes)
+
+			var sizes []int
+			total := 0
+			for _, chunk := range chunks {
+				sizes = append(sizes, len(chunk))
+				total += len(chunk)
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.want) {
+				t.Errorf("chunk sizes = %v, want %v", sizes, tt.want)
+			}
+			if total != len(data) {
+				t.Errorf("chunks contain %d metrics, want %d", total, len(data))
+			}
+		})
+	}
+
+	if chunks := SplitMetrics(nil, 10, 100); len(chunks) != 0 {
+		t.Errorf("SplitMetrics(nil) = %d chunks, want 0", len(chunks))
+	}
+}
+
+func TestSenderSend(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run("compress="+strconv.FormatBool(compress), func(t *testing.T) {
+			var received Packet
+			sender := startTrapper(t, func(request []byte) []byte {
+				if err := json.Unmarshal(request, &received); err != nil {
+					t.Errorf("trapper got invalid request: %v", err)
+				}
+				return senderReply(len(received.Data), 0)
+			})
+			sender.Compress = compress
+
+			data := []*Metric{NewMetric("h", "a", "1", 1), NewMetric("h", "b", "2", 1)}
+			resp, err := sender.SendContext(context.Background(), NewPacket(data))
+			if err != nil {
+				t.Fatalf("SendContext: %v", err)
+			}
+			if resp.Processed != 2 || resp.Failed != 0 || resp.Total != 2 {
+				t.Errorf("response = %+v, want processed 2, failed 0", resp)
+			}
+			if received.Request != "sender data" || len(received.Data) != 2 || received.Data[1].Key != "b" {
+				t.Errorf("trapper received %+v", received)
+			}
+		})
+	}
+}
+
+func TestSenderFailedValues(t *testing.T) {
+	sender := startTrapper(t, func([]byte) []byte {
+		return senderReply(1, 2)
+	})
+
+	data := []*Metric{NewMetric("h", "a", "1", 1), NewMetric("h", "b", "x", 1), NewMetric("h", "c", "x", 1)}
+	resp, err := sender.SendContext(context.Background(), NewPacket(data))
+
+	var failedErr *FailedValuesError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("error = %v, want *FailedValuesError", err)
+	}
+	if resp == nil || resp.Processed != 1 || resp.Failed != 2 || resp.Total != 3 {
+		t.Errorf("response = %+v, want processed 1, failed 2, total 3", resp)
+	}
+	if failedErr.Response.Failed != 2 || len(failedErr.Metrics) != 3 {
+		t.Errorf("FailedValuesError = %+v, want 2 failed of 3 metrics", failedErr)
+	}
+}
+
+func TestClientSendDataFailedValues(t *testing.T) {
+	sender := startTrapper(t, func(request []byte) []byte {
+		var packet Packet
+		json.Unmarshal(request, &packet)
+
+		// Отклоняем значения с ключом bad
+		failed := 0
+		for _, metric := range packet.Data {
+			if metric.Key == "bad" {
+				failed++
+			}
+		}
+		return senderReply(len(packet.Data)-failed, failed)
+	})
+
+	client := newTestClient(t, sender)
+	client.SetBatchConfig(BatchConfig{Size: 2})
+
+	data := []*Metric{
+		NewMetric("h", "a", "1", 1), NewMetric("h", "b", "1", 1),
+		NewMetric("h", "bad", "x", 1), NewMetric("h", "c", "1", 1),
+		NewMetric("h", "d", "1", 1),
+	}
+	resp, err := client.SendData(context.Background(), data)
+
+	var failedErr *FailedValuesError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("error = %v, want *FailedValuesError", err)
+	}
+	if resp.Processed != 4 || resp.Failed != 1 || resp.Total != 5 {
+		t.Errorf("merged response = %+v, want processed 4, failed 1, total 5", resp)
+	}
+	// Метрики отклоненного пакета - второй пакет из двух значений
+	if len(failedErr.Metrics) != 2 || failedErr.Metrics[0].Key != "bad" {
+		t.Errorf("rejected metrics = %v, want chunk with bad key", failedErr.Metrics)
+	}
+}
+
+func TestFindRejectedSingleTarget(t *testing.T) {
+	// Оба адреса отклоняют значения с ключом bad и считают полученные пакеты
+	var mu sync.Mutex
+	received := make([]int, 2)
+	trapper := func(i int) func([]byte) []byte {
+		return func(request []byte) []byte {
+			var packet Packet
+			json.Unmarshal(request, &packet)
+
+			mu.Lock()
+			received[i]++
+			mu.Unlock()
+
+			failed := 0
+			for _, metric := range packet.Data {
+				if metric.Key == "bad" {
+					failed++
+				}
+			}
+			return senderReply(len(packet.Data)-failed, failed)
+		}
+	}
+	primary, secondary := startTrapper(t, trapper(0)), startTrapper(t, trapper(1))
+
+	client := NewClient("http://127.0.0.1/api_jsonrpc.php", "", "", time.Second, zap.NewNop())
+	client.SetSenderConfig(SenderConfig{
+		Servers:      []string{primary.Address(), secondary.Address()},
+		Policy:       PolicyFanout,
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	})
+	if err := client.InitSenders(); err != nil {
+		t.Fatalf("InitSenders: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	var data []*Metric
+	for i := 0; i < 8; i++ {
+		data = append(data, NewMetric("h", "k"+strconv.Itoa(i), "1", 1))
+	}
+	data[5].Key = "bad"
+
+	rejected, err := client.FindRejected(context.Background(), &FailedValuesError{
+		Response: &SenderResponse{Processed: 7, Failed: 1, Total: 8},
+		Metrics:  data,
+	})
+	if err != nil {
+		t.Fatalf("FindRejected: %v", err)
+	}
+	if len(rejected) != 1 || rejected[0].Key != "bad" {
+		t.Errorf("rejected = %v, want bad key", rejected)
+	}
+	if received[0] == 0 || received[1] != 0 {
+		t.Errorf("probes received by targets = %v, want only the first target", received)
+	}
+}
+
+// newTestClient создает клиент, отправляющий данные через sender
+func newTestClient(t *testing.T, sender *Sender) *Client {
+	t.Helper()
+
+	client := NewClient("http://127.0.0.1/api_jsonrpc.php", "", "", time.Second, zap.NewNop())
+	client.SetSenderConfig(SenderConfig{
+		Servers:      []string{sender.Address()},
+		DialTimeout:  time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	})
+	if err := client.InitSenders(); err != nil {
+		t.Fatalf("InitSenders: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
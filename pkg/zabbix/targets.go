@@ -0,0 +1,173 @@
+package zabbix
+
+import (
+	"sync"
+	"time"
+)
+
+// Политики доставки при нескольких адресах trapper
+const (
+	PolicyFailover = "failover" // адреса перебираются по порядку, пока один не примет пакет
+	PolicyFanout   = "fanout"   // пакет отправляется на все адреса
+)
+
+// Состояния автоматического выключателя
+const (
+	BreakerClosed   = "closed"    // адрес доступен
+	BreakerOpen     = "open"      // адрес пропускается до окончания паузы
+	BreakerHalfOpen = "half-open" // выполняется пробная отправка
+)
+
+// Значения по умолчанию для автоматического выключателя
+const (
+	DefaultBreakerThreshold = 3
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// circuitBreaker отслеживает доступность адреса trapper. После threshold ошибок подряд
+// адрес пропускается на время cooldown, затем допускается одна пробная отправка.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// allow проверяет, можно ли отправлять данные на адрес
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	case BreakerHalfOpen:
+		// Пробная отправка уже выполняется
+		return false
+	default:
+		return true
+	}
+}
+
+// success фиксирует успешную доставку
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+}
+
+// failure фиксирует ошибку доставки
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release возвращает выключатель в открытое состояние, если пробная отправка была прервана
+// без результата. Следующая отправка снова станет пробной.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+	}
+}
+
+// currentState возвращает текущее состояние выключателя
+func (b *circuitBreaker) currentState() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// TargetStatus содержит состояние доставки на один адрес trapper
+type TargetStatus struct {
+	Address   string `json:"address"`
+	State     string `json:"state"`
+	Sent      uint64 `json:"sent"`
+	Failed    uint64 `json:"failed"`
+	Skipped   uint64 `json:"skipped"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// target адрес trapper с собственным выключателем и статистикой
+type target struct {
+	sender  *Sender
+	breaker *circuitBreaker
+
+	mu      sync.Mutex
+	sent    uint64
+	failed  uint64
+	skipped uint64
+	lastErr error
+}
+
+// record сохраняет результат отправки на адрес
+func (t *target) record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		t.failed++
+		t.lastErr = err
+		t.breaker.failure()
+		return
+	}
+	t.sent++
+	t.lastErr = nil
+	t.breaker.success()
+}
+
+// skip учитывает пропуск адреса из-за открытого выключателя
+func (t *target) skip() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.skipped++
+}
+
+// status возвращает состояние доставки на адрес
+func (t *target) status() TargetStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := TargetStatus{
+		Address: t.sender.Address(),
+		State:   t.breaker.currentState(),
+		Sent:    t.sent,
+		Failed:  t.failed,
+		Skipped: t.skipped,
+	}
+	if t.lastErr != nil {
+		status.LastError = t.lastErr.Error()
+	}
+	return status
+}
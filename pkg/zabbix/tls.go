@@ -0,0 +1,101 @@
+package zabbix
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Режимы шифрования соединения с сервером, как в параметре TLSConnect zabbix_agentd
+const (
+	TLSConnectUnencrypted = "unencrypted"
+	TLSConnectCert        = "cert"
+	TLSConnectPSK         = "psk" // не поддерживается: в crypto/tls нет наборов шифров PSK
+)
+
+// TLSOptions содержит параметры шифрования соединения с сервером,
+// повторяющие параметры TLS* zabbix_agentd
+type TLSOptions struct {
+	Connect           string // TLSConnect: unencrypted или cert
+	CAFile            string // TLSCAFile
+	CertFile          string // TLSCertFile
+	KeyFile           string // TLSKeyFile
+	ServerCertIssuer  string // TLSServerCertIssuer
+	ServerCertSubject string // TLSServerCertSubject
+}
+
+// NewTLSConfig создает конфигурацию TLS для соединения с сервером.
+// Для режима unencrypted возвращает nil.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	switch opts.Connect {
+	case "", TLSConnectUnencrypted:
+		return nil, nil
+	case TLSConnectCert:
+	case TLSConnectPSK:
+		return nil, errors.New("TLS connect mode psk is not supported, use cert")
+	default:
+		return nil, fmt.Errorf("unsupported TLS connect mode: %s", opts.Connect)
+	}
+
+	if opts.CAFile == "" || opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("TLS CA file, certificate file and key file are required for certificate mode")
+	}
+
+	caData, err := os.ReadFile(opts.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf("no certificates found in TLS CA file %s", opts.CAFile)
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		// Как и zabbix_agentd, не проверяем имя хоста сервера: сертификат проверяется
+		// по цепочке доверия и, при необходимости, по эмитенту и субъекту
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return verifyServerCert(state, roots, opts.ServerCertIssuer, opts.ServerCertSubject)
+		},
+	}, nil
+}
+
+// verifyServerCert проверяет цепочку сертификата сервера, его эмитента и субъекта
+func verifyServerCert(state tls.ConnectionState, roots *x509.CertPool, issuer, subject string) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return fmt.Errorf("failed to verify server certificate: %w", err)
+	}
+
+	// Имена сравниваются в формате RFC 4514, как в zabbix_agentd
+	if issuer != "" && leaf.Issuer.String() != issuer {
+		return fmt.Errorf("server certificate issuer %q does not match %q", leaf.Issuer.String(), issuer)
+	}
+	if subject != "" && leaf.Subject.String() != subject {
+		return fmt.Errorf("server certificate subject %q does not match %q", leaf.Subject.String(), subject)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package zabbix
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCert сертификат с ключом, выпущенный в тесте
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// issueCert выпускает сертификат; parent == nil - самоподписанный сертификат CA
+func issueCert(t *testing.T, subject pkix.Name, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// writePEM сохраняет сертификат и ключ в PEM файлы и возвращает их пути
+func (c *testCert) writePEM(t *testing.T, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// startTLSTrapper запускает trapper с сертификатом server и возвращает Sender для него
+func startTLSTrapper(t *testing.T, server *testCert) *Sender {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.der}, PrivateKey: server.key}},
+		ClientAuth:   tls.RequireAnyClientCert,
+	})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	serveTrapper(t, ln, func([]byte) []byte {
+		return senderReply(1, 0)
+	})
+
+	addr := ln.Addr().(*net.TCPAddr)
+	sender := NewSender(addr.IP.String(), addr.Port)
+	sender.DialTimeout = time.Second
+	sender.ReadTimeout = time.Second
+	sender.WriteTimeout = time.Second
+	t.Cleanup(sender.Close)
+	return sender
+}
+
+func TestTLSServerVerification(t *testing.T) {
+	ca := issueCert(t, pkix.Name{CommonName: "Zabbix CA", Organization: []string{"Zabbix"}}, nil, 0)
+	otherCA := issueCert(t, pkix.Name{CommonName: "Other CA"}, nil, 0)
+	server := issueCert(t, pkix.Name{CommonName: "zabbix-server", Organization: []string{"Zabbix"}}, ca, x509.ExtKeyUsageServerAuth)
+	client := issueCert(t, pkix.Name{CommonName: "zabbix-agent"}, ca, x509.ExtKeyUsageClientAuth)
+	clientOnly := issueCert(t, pkix.Name{CommonName: "zabbix-server"}, ca, x509.ExtKeyUsageClientAuth)
+
+	caFile, _ := ca.writePEM(t, "ca")
+	otherCAFile, _ := otherCA.writePEM(t, "other-ca")
+	certFile, keyFile := client.writePEM(t, "agent")
+
+	tests := []struct {
+		name    string
+		server  *testCert
+		caFile  string
+		issuer  string
+		subject string
+		wantErr string
+	}{
+		{name: "good chain", server: server, caFile: caFile},
+		{
+			name:    "issuer and subject match",
+			server:  server,
+			caFile:  caFile,
+			issuer:  "CN=Zabbix CA,O=Zabbix",
+			subject: "CN=zabbix-server,O=Zabbix",
+		},
+		{name: "wrong CA", server: server, caFile: otherCAFile, wantErr: "failed to verify server certificate"},
+		{name: "issuer mismatch", server: server, caFile: caFile, issuer: "CN=Other CA", wantErr: "issuer"},
+		{name: "subject mismatch", server: server, caFile: caFile, subject: "CN=other-server,O=Zabbix", wantErr: "subject"},
+		{name: "certificate without server auth", server: clientOnly, caFile: caFile, wantErr: "failed to verify server certificate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewTLSConfig(TLSOptions{
+				Connect:           TLSConnectCert,
+				CAFile:            tt.caFile,
+				CertFile:          certFile,
+				KeyFile:           keyFile,
+				ServerCertIssuer:  tt.issuer,
+				ServerCertSubject: tt.subject,
+			})
+			if err != nil {
+				t.Fatalf("NewTLSConfig: %v", err)
+			}
+
+			sender := startTLSTrapper(t, tt.server)
+			sender.TLS = config
+
+			resp, err := sender.SendContext(context.Background(), NewPacket([]*Metric{NewMetric("h", "k", "1", 1)}))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("SendContext: %v", err)
+				}
+				if resp.Processed != 1 {
+					t.Errorf("processed = %d, want 1", resp.Processed)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfigValidation(t *testing.T) {
+	ca := issueCert(t, pkix.Name{CommonName: "Zabbix CA"}, nil, 0)
+	client := issueCert(t, pkix.Name{CommonName: "zabbix-agent"}, ca, x509.ExtKeyUsageClientAuth)
+	caFile, _ := ca.writePEM(t, "ca")
+	certFile, keyFile := client.writePEM(t, "agent")
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "default", opts: TLSOptions{}, wantNil: true},
+		{name: "unencrypted", opts: TLSOptions{Connect: TLSConnectUnencrypted, CAFile: caFile}, wantNil: true},
+		{name: "psk", opts: TLSOptions{Connect: TLSConnectPSK}, wantErr: true},
+		{name: "unknown mode", opts: TLSOptions{Connect: "tls"}, wantErr: true},
+		{name: "cert without files", opts: TLSOptions{Connect: TLSConnectCert, CAFile: caFile}, wantErr: true},
+		{name: "missing CA file", opts: TLSOptions{Connect: TLSConnectCert, CAFile: caFile + ".missing", CertFile: certFile, KeyFile: keyFile}, wantErr: true},
+		{name: "CA file without certificates", opts: TLSOptions{Connect: TLSConnectCert, CAFile: keyFile, CertFile: certFile, KeyFile: keyFile}, wantErr: true},
+		{name: "key does not match certificate", opts: TLSOptions{Connect: TLSConnectCert, CAFile: caFile, CertFile: caFile, KeyFile: keyFile}, wantErr: true},
+		{name: "cert", opts: TLSOptions{Connect: TLSConnectCert, CAFile: caFile, CertFile: certFile, KeyFile: keyFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewTLSConfig(tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NewTLSConfig accepted invalid options")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTLSConfig: %v", err)
+			}
+			if (config == nil) != tt.wantNil {
+				t.Errorf("config = %v, want nil %v", config, tt.wantNil)
+			}
+		})
+	}
+}
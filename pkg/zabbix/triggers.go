@@ -0,0 +1,387 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// triggerOverride описание триггера в файле конфигурации. Запись с именем встроенного
+// триггера изменяет только заданные поля, остальные записи добавляют новые триггеры.
+type triggerOverride struct {
+	Name         string       `json:"name"`
+	Expression   string       `json:"expression,omitempty"`
+	Threshold    string       `json:"threshold,omitempty"`
+	Severity     *int         `json:"severity,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Tags         []TriggerTag `json:"tags,omitempty"`
+	Dependencies []string     `json:"dependencies,omitempty"`
+	Disabled     bool         `json:"disabled,omitempty"` // не создавать встроенный триггер
+}
+
+// LoadTriggers возвращает встроенные триггеры, дополненные описаниями из JSON файла.
+// Пустой путь - только встроенные триггеры.
+func LoadTriggers(path string) ([]ZabbixTrigger, error) {
+	triggers := GetZabbixTriggers()
+	for i := range triggers {
+		triggers[i].Builtin = true
+	}
+	if path == "" {
+		return triggers, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read triggers file: %w", err)
+	}
+
+	var overrides []triggerOverride
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse triggers file %s: %w", path, err)
+	}
+
+	index := make(map[string]int, len(triggers))
+	for i, trigger := range triggers {
+		index[trigger.Name] = i
+	}
+
+	disabled := make(map[string]bool)
+	for _, override := range overrides {
+		if override.Name == "" {
+			return nil, fmt.Errorf("trigger without name in %s", path)
+		}
+		if override.Severity != nil && (*override.Severity < SeverityNotClassified || *override.Severity > SeverityDisaster) {
+			return nil, fmt.Errorf("trigger '%s' has invalid severity %d", override.Name, *override.Severity)
+		}
+
+		i, exists := index[override.Name]
+		if !exists {
+			if override.Expression == "" {
+				return nil, fmt.Errorf("trigger '%s' has no expression", override.Name)
+			}
+			index[override.Name] = len(triggers)
+			triggers = append(triggers, ZabbixTrigger{Name: override.Name})
+			i = len(triggers) - 1
+		}
+
+		trigger := &triggers[i]
+		if override.Disabled {
+			disabled[trigger.Name] = true
+			continue
+		}
+		if override.Expression != "" {
+			// Выражение пользователя проверяет сервер, фильтр включенных ключей не применяется
+			trigger.Expression = override.Expression
+			trigger.Builtin = false
+		}
+		if override.Threshold != "" {
+			trigger.Threshold = override.Threshold
+		}
+		if override.Severity != nil {
+			trigger.Severity = *override.Severity
+		}
+		if override.Description != "" {
+			trigger.Description = override.Description
+		}
+		if override.Tags != nil {
+			trigger.Tags = override.Tags
+		}
+		if override.Dependencies != nil {
+			trigger.Dependencies = override.Dependencies
+		}
+	}
+
+	result := triggers[:0]
+	for _, trigger := range triggers {
+		if !disabled[trigger.Name] {
+			result = append(result, trigger)
+		}
+	}
+	return result, nil
+}
+
+// SetTriggers задает триггеры, которые создаются и обновляются при инициализации
+func (c *Client) SetTriggers(triggers []ZabbixTrigger) {
+	c.triggers = triggers
+}
+
+// expressionKeys возвращает ключи элементов данных хоста, на которые ссылается выражение
+func expressionKeys(expression string) []string {
+	const prefix = "/{HOST}/"
+
+	var keys []string
+	for {
+		start := strings.Index(expression, prefix)
+		if start < 0 {
+			return keys
+		}
+		expression = expression[start+len(prefix):]
+
+		// Ключ заканчивается запятой или скобкой вне параметров ключа
+		depth, end := 0, len(expression)
+	scan:
+		for i, ch := range expression {
+			switch ch {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			case ',', ')':
+				if depth == 0 {
+					end = i
+					break scan
+				}
+			}
+		}
+		keys = append(keys, expression[:end])
+		expression = expression[end:]
+	}
+}
+
+// renderExpression подставляет имя хоста и порог в выражение триггера
+func renderExpression(trigger ZabbixTrigger, hostName string) string {
+	return strings.NewReplacer("{HOST}", hostName, "{THRESHOLD}", trigger.Threshold).Replace(trigger.Expression)
+}
+
+// normalizeExpression убирает пробелы, которые сервер может добавить или удалить
+func normalizeExpression(expression string) string {
+	return strings.Join(strings.Fields(expression), "")
+}
+
+// isManagedTrigger проверяет наличие тега триггера, созданного утилитой
+func isManagedTrigger(tags []TriggerTag) bool {
+	for _, tag := range tags {
+		if tag.Tag == ManagedTag && tag.Value == ManagedTagValue {
+			return true
+		}
+	}
+	return false
+}
+
+// disabledKey возвращает первый ключ выражения, который не публикуется включенными источниками.
+// Для выражений пользователя ключи элементов обнаружения тоже считаются публикуемыми.
+func (c *Client) disabledKey(expression string, builtin bool) (string, bool) {
+	for _, key := range expressionKeys(expression) {
+		if builtin && !c.isKeyEnabled(key) || !builtin && !c.IsKnownKey(key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// sameTags сравнивает теги без учета порядка
+func sameTags(a, b []TriggerTag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	key := func(tags []TriggerTag) []string {
+		result := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			result = append(result, tag.Tag+"\x00"+tag.Value)
+		}
+		sort.Strings(result)
+		return result
+	}
+	ka, kb := key(a), key(b)
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameIDs сравнивает наборы идентификаторов без учета порядка
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// getTriggers загружает триггеры хоста (без унаследованных от шаблонов) по имени
+func (c *Client) getTriggers(ctx context.Context) (map[string]Trigger, error) {
+	params := TriggerGetParams{
+		Output:             []string{"triggerid", "description", "expression", "priority", "comments"},
+		HostIDs:            []string{c.hostID},
+		SelectTags:         []string{"tag", "value"},
+		SelectDependencies: []string{"triggerid"},
+		ExpandExpression:   true,
+		Inherited:          false,
+	}
+
+	resp, err := c.makeRequest(ctx, "trigger.get", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get triggers: %w", err)
+	}
+
+	var triggers []Trigger
+	if err := json.Unmarshal(resp.Result, &triggers); err != nil {
+		return nil, fmt.Errorf("failed to parse triggers: %w", err)
+	}
+
+	result := make(map[string]Trigger, len(triggers))
+	for _, trigger := range triggers {
+		result[trigger.Description] = trigger
+	}
+	return result, nil
+}
+
+// syncTriggers создает отсутствующие и обновляет измененные триггеры.
+// Триггеры сопоставляются по имени; обновляются только триггеры с тегом managed-by,
+// триггеры оператора с тем же именем не изменяются. Встроенные триггеры, ссылающиеся
+// на отключенные ключи, пропускаются; выражения пользователя (в том числе на элементы
+// обнаружения) проверяет сервер. Ошибка одного триггера не мешает обработке остальных.
+// Выражения используют синтаксис Zabbix 5.4+, на более старых серверах триггеры не создаются.
+func (c *Client) syncTriggers(ctx context.Context) error {
+	c.authMutex.RLock()
+	version := c.apiVersion
+	c.authMutex.RUnlock()
+	if !version.usesNewExpressions() {
+		c.logger.Warn("Skipping triggers, expressions require Zabbix 5.4 or newer",
+			zap.Stringer("api_version", version))
+		return nil
+	}
+
+	c.logger.Info("Synchronizing triggers")
+
+	existing, err := c.getTriggers(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Идентификаторы управляемых триггеров для разрешения зависимостей
+	ids := make(map[string]string, len(c.triggers))
+	var managed []ZabbixTrigger
+	created, updated, failed := 0, 0, 0
+
+	for _, trigger := range c.triggers {
+		if key, disabled := c.disabledKey(trigger.Expression, trigger.Builtin); disabled {
+			if trigger.Builtin {
+				c.logger.Debug("Skipping trigger for disabled key",
+					zap.String("trigger", trigger.Name),
+					zap.String("key", key))
+				continue
+			}
+			c.logger.Warn("Trigger references a key that is not collected, creating it anyway",
+				zap.String("trigger", trigger.Name),
+				zap.String("key", key))
+		}
+
+		params := TriggerParams{
+			Description: trigger.Name,
+			Expression:  renderExpression(trigger, c.hostName),
+			Priority:    trigger.Severity,
+			Comments:    trigger.Description,
+			Tags:        append(append([]TriggerTag{}, trigger.Tags...), TriggerTag{Tag: ManagedTag, Value: ManagedTagValue}),
+		}
+
+		current, exists := existing[trigger.Name]
+		switch {
+		case exists && !isManagedTrigger(current.Tags):
+			c.logger.Warn("Trigger with the same name was not created by zabbix_mon, leaving it unchanged",
+				zap.String("trigger", trigger.Name),
+				zap.String("triggerID", current.TriggerID))
+			continue
+
+		case !exists && c.dryRun():
+			c.logger.Info("Trigger will be created (dry run)", zap.String("trigger", trigger.Name))
+			created++
+			continue
+
+		case !exists:
+			resp, err := c.makeRequest(ctx, "trigger.create", params)
+			if err != nil {
+				c.logger.Warn("Failed to create trigger", zap.String("trigger", trigger.Name), zap.Error(err))
+				failed++
+				continue
+			}
+			var result map[string][]string
+			if err := json.Unmarshal(resp.Result, &result); err != nil || len(result["triggerids"]) == 0 {
+				c.logger.Warn("Failed to parse trigger create result", zap.String("trigger", trigger.Name))
+				failed++
+				continue
+			}
+			ids[trigger.Name] = result["triggerids"][0]
+			existing[trigger.Name] = Trigger{TriggerID: result["triggerids"][0], Tags: params.Tags}
+			created++
+
+		case normalizeExpression(current.Expression) != normalizeExpression(params.Expression) ||
+			current.Priority != strconv.Itoa(params.Priority) ||
+			current.Comments != params.Comments ||
+			!sameTags(current.Tags, params.Tags):
+			if c.dryRun() {
+				c.logger.Info("Trigger will be updated (dry run)",
+					zap.String("trigger", trigger.Name),
+					zap.String("triggerID", current.TriggerID))
+				updated++
+				continue
+			}
+			params.TriggerID = current.TriggerID
+			if _, err := c.makeRequest(ctx, "trigger.update", params); err != nil {
+				c.logger.Warn("Failed to update trigger", zap.String("trigger", trigger.Name), zap.Error(err))
+				failed++
+				continue
+			}
+			ids[trigger.Name] = current.TriggerID
+			updated++
+
+		default:
+			ids[trigger.Name] = current.TriggerID
+		}
+		managed = append(managed, trigger)
+	}
+
+	// Зависимости обновляются после создания всех триггеров, от которых они зависят
+	for _, trigger := range managed {
+		var want []string
+		for _, name := range trigger.Dependencies {
+			if id, ok := ids[name]; ok {
+				want = append(want, id)
+			} else if dep, ok := existing[name]; ok {
+				want = append(want, dep.TriggerID)
+			}
+		}
+
+		var have []string
+		for _, dep := range existing[trigger.Name].Dependencies {
+			have = append(have, dep.TriggerID)
+		}
+		if sameIDs(want, have) {
+			continue
+		}
+		if c.dryRun() {
+			c.logger.Info("Trigger dependencies will be updated (dry run)", zap.String("trigger", trigger.Name))
+			continue
+		}
+
+		params := TriggerDependenciesParams{TriggerID: ids[trigger.Name], Dependencies: []TriggerID{}}
+		for _, id := range want {
+			params.Dependencies = append(params.Dependencies, TriggerID{TriggerID: id})
+		}
+		if _, err := c.makeRequest(ctx, "trigger.update", params); err != nil {
+			c.logger.Warn("Failed to update trigger dependencies", zap.String("trigger", trigger.Name), zap.Error(err))
+			failed++
+		}
+	}
+
+	c.logger.Info("Triggers synchronized",
+		zap.Int("created", created),
+		zap.Int("updated", updated),
+		zap.Int("failed", failed))
+	return nil
+}
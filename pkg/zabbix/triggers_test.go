@@ -0,0 +1,81 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestSyncTriggers(t *testing.T) {
+	managed := []TriggerTag{{Tag: ManagedTag, Value: ManagedTagValue}}
+	client, stub := startAPI(t, map[string]func(json.RawMessage) interface{}{
+		"trigger.get": func(json.RawMessage) interface{} {
+			return []Trigger{
+				// Триггер оператора с именем встроенного триггера
+				{TriggerID: "1", Description: "High CPU utilization", Expression: "avg(/web-1/system.cpu.util,1m)>99", Priority: "5"},
+				// Управляемый триггер с устаревшим порогом
+				{TriggerID: "2", Description: "High memory utilization", Expression: "min(/web-1/vm.memory.util,5m)>80", Priority: "2", Tags: managed},
+			}
+		},
+		"trigger.create": func(json.RawMessage) interface{} {
+			return map[string][]string{"triggerids": {"3"}}
+		},
+		"trigger.update": func(json.RawMessage) interface{} {
+			return map[string][]string{"triggerids": {"2"}}
+		},
+	})
+	client.apiVersion = apiVersion{major: 6, minor: 0}
+	client.SetEnabledKeys([]string{"system.cpu.util[,idle]", "vm.memory.util"})
+	client.SetTriggers([]ZabbixTrigger{
+		{Name: "High CPU utilization", Expression: "min(/{HOST}/system.cpu.util[,idle],5m)<{THRESHOLD}", Threshold: "10", Builtin: true},
+		{Name: "High memory utilization", Expression: "min(/{HOST}/vm.memory.util,5m)>{THRESHOLD}", Threshold: "90", Builtin: true},
+		{Name: "High swap space usage", Expression: "max(/{HOST}/system.swap.size[,pfree],5m)<{THRESHOLD}", Threshold: "50", Builtin: true},
+		{Name: "Root filesystem is full", Expression: "last(/{HOST}/vfs.fs.pused[/])>90"},
+	})
+
+	if err := client.syncTriggers(context.Background()); err != nil {
+		t.Fatalf("syncTriggers: %v", err)
+	}
+
+	// Создается только триггер пользователя: встроенный триггер swap ссылается на отключенный ключ
+	creates := stub.methodCalls("trigger.create")
+	if len(creates) != 1 {
+		t.Fatalf("trigger.create called %d times, want 1", len(creates))
+	}
+	var created TriggerParams
+	json.Unmarshal(creates[0], &created)
+	if created.Description != "Root filesystem is full" || created.Expression != "last(/web-1/vfs.fs.pused[/])>90" {
+		t.Errorf("created trigger = %+v", created)
+	}
+	if !isManagedTrigger(created.Tags) {
+		t.Errorf("created trigger tags = %+v, want %s=%s", created.Tags, ManagedTag, ManagedTagValue)
+	}
+
+	// Обновляется только управляемый триггер, триггер оператора не изменяется
+	updates := stub.methodCalls("trigger.update")
+	if len(updates) != 1 {
+		t.Fatalf("trigger.update called %d times, want 1", len(updates))
+	}
+	var updated TriggerParams
+	json.Unmarshal(updates[0], &updated)
+	if updated.TriggerID != "2" || updated.Expression != "min(/web-1/vm.memory.util,5m)>90" {
+		t.Errorf("updated trigger = %+v", updated)
+	}
+}
+
+func TestSyncTriggersBefore54(t *testing.T) {
+	client, stub := startAPI(t, map[string]func(json.RawMessage) interface{}{})
+	client.apiVersion = apiVersion{major: 5, minor: 0}
+	client.SetEnabledKeys([]string{"vm.memory.util"})
+	client.SetTriggers([]ZabbixTrigger{
+		{Name: "High memory utilization", Expression: "min(/{HOST}/vm.memory.util,5m)>{THRESHOLD}", Threshold: "90", Builtin: true},
+	})
+
+	// Старый сервер не понимает синтаксис выражений 5.4, к API не обращаемся
+	if err := client.syncTriggers(context.Background()); err != nil {
+		t.Fatalf("syncTriggers: %v", err)
+	}
+	if len(stub.calls) != 0 {
+		t.Errorf("syncTriggers made %d requests on Zabbix 5.0", len(stub.calls))
+	}
+}
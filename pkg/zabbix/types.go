@@ -28,55 +28,243 @@ type JSONRPCError struct {
 
 // LoginParams параметры для авторизации
 type LoginParams struct {
-	User     string `json:"user"`
+	Username string `json:"username,omitempty"` // Zabbix 5.4 и новее
+	User     string `json:"user,omitempty"`     // до Zabbix 5.4
 	Password string `json:"password"`
 }
 
 // HostGetParams параметры для получения хоста
 type HostGetParams struct {
-	Output []string          `json:"output"`
-	Filter map[string]string `json:"filter"`
+	Output                []string          `json:"output"`
+	Filter                map[string]string `json:"filter"`
+	SelectTags            []string          `json:"selectTags,omitempty"`
+	SelectParentTemplates []string          `json:"selectParentTemplates,omitempty"`
 }
 
 // Host представляет хост в Zabbix
 type Host struct {
-	HostID string `json:"hostid"`
-	Host   string `json:"host"`
-	Name   string `json:"name"`
-	Status string `json:"status"`
+	HostID          string     `json:"hostid"`
+	Host            string     `json:"host"`
+	Name            string     `json:"name"`
+	Status          string     `json:"status"`
+	Tags            []HostTag  `json:"tags,omitempty"`
+	ParentTemplates []Template `json:"parentTemplates,omitempty"`
+}
+
+// HostTag представляет тег хоста
+type HostTag struct {
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+// HostInterface представляет интерфейс хоста
+type HostInterface struct {
+	Type  string `json:"type"`
+	Main  string `json:"main"`
+	UseIP string `json:"useip"`
+	IP    string `json:"ip"`
+	DNS   string `json:"dns"`
+	Port  string `json:"port"`
+}
+
+// HostGroup представляет группу узлов сети
+type HostGroup struct {
+	GroupID string `json:"groupid"`
+	Name    string `json:"name"`
+}
+
+// HostGroupID ссылка на группу узлов сети
+type HostGroupID struct {
+	GroupID string `json:"groupid"`
+}
+
+// Template представляет шаблон
+type Template struct {
+	TemplateID string `json:"templateid"`
+	Host       string `json:"host"`
+}
+
+// TemplateID ссылка на шаблон
+type TemplateID struct {
+	TemplateID string `json:"templateid"`
+}
+
+// HostCreateParams параметры для создания хоста
+type HostCreateParams struct {
+	Host       string          `json:"host"`
+	Name       string          `json:"name,omitempty"`
+	Groups     []HostGroupID   `json:"groups"`
+	Templates  []TemplateID    `json:"templates,omitempty"`
+	Tags       []HostTag       `json:"tags,omitempty"`
+	Interfaces []HostInterface `json:"interfaces"`
+}
+
+// HostUpdateParams параметры для обновления хоста
+type HostUpdateParams struct {
+	HostID    string       `json:"hostid"`
+	Name      string       `json:"name,omitempty"`
+	Tags      []HostTag    `json:"tags"`
+	Templates []TemplateID `json:"templates"`
 }
 
 // ItemGetParams параметры для получения элементов данных
 type ItemGetParams struct {
-	Output  []string          `json:"output"`
-	HostIDs []string          `json:"hostids"`
-	Filter  map[string]string `json:"filter,omitempty"`
+	Output     []string          `json:"output"`
+	HostIDs    []string          `json:"hostids"`
+	Filter     map[string]string `json:"filter,omitempty"`
+	SelectTags []string          `json:"selectTags,omitempty"`
 }
 
 // Item представляет элемент данных в Zabbix
 type Item struct {
-	ItemID      string `json:"itemid"`
+	ItemID      string    `json:"itemid"`
+	Name        string    `json:"name"`
+	Key         string    `json:"key_"`
+	HostID      string    `json:"hostid"`
+	Status      string    `json:"status"`
+	ValueType   string    `json:"value_type"`
+	DataType    string    `json:"data_type"`
+	Description string    `json:"description"`
+	Units       string    `json:"units"`
+	Flags       string    `json:"flags"`      // 0 - обычный, 4 - создан обнаружением
+	TemplateID  string    `json:"templateid"` // не 0 - унаследован от шаблона
+	Tags        []ItemTag `json:"tags,omitempty"`
+}
+
+// ItemTag представляет тег элемента данных
+type ItemTag struct {
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+// ItemCreateParams параметры для создания элемента данных
+type ItemCreateParams struct {
+	Name        string    `json:"name"`
+	Key         string    `json:"key_"`
+	HostID      string    `json:"hostid"`
+	Type        int       `json:"type"`       // 2 - Zabbix trapper
+	ValueType   int       `json:"value_type"` // 0 - float, 3 - unsigned int
+	DataType    int       `json:"data_type"`  // 0 - decimal
+	Description string    `json:"description,omitempty"`
+	Units       string    `json:"units,omitempty"`
+	Status      int       `json:"status"` // 0 - enabled
+	Tags        []ItemTag `json:"tags,omitempty"`
+}
+
+// ItemUpdateParams параметры для приведения элемента данных к каталогу
+type ItemUpdateParams struct {
+	ItemID      string    `json:"itemid"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ValueType   int       `json:"value_type"`
+	Units       string    `json:"units,omitempty"` // пусто - единицы не изменяются
+	Tags        []ItemTag `json:"tags,omitempty"`
+}
+
+// ItemStatusParams параметры для включения или отключения элемента данных
+type ItemStatusParams struct {
+	ItemID string `json:"itemid"`
+	Status int    `json:"status"` // 0 - enabled, 1 - disabled
+}
+
+// DiscoveryRuleGetParams параметры для получения правил обнаружения
+type DiscoveryRuleGetParams struct {
+	Output  []string `json:"output"`
+	HostIDs []string `json:"hostids"`
+}
+
+// DiscoveryRule представляет правило низкоуровневого обнаружения в Zabbix
+type DiscoveryRule struct {
+	ItemID string `json:"itemid"`
+	Name   string `json:"name"`
+	Key    string `json:"key_"`
+}
+
+// DiscoveryRuleCreateParams параметры для создания правила обнаружения
+type DiscoveryRuleCreateParams struct {
 	Name        string `json:"name"`
 	Key         string `json:"key_"`
 	HostID      string `json:"hostid"`
-	Status      string `json:"status"`
-	ValueType   string `json:"value_type"`
-	DataType    string `json:"data_type"`
-	Description string `json:"description"`
+	Type        int    `json:"type"` // 2 - Zabbix trapper
+	Lifetime    string `json:"lifetime,omitempty"`
+	Description string `json:"description,omitempty"`
+	Status      int    `json:"status"` // 0 - enabled
 }
 
-// ItemCreateParams параметры для создания элемента данных
-type ItemCreateParams struct {
+// ItemPrototypeGetParams параметры для получения прототипов элементов данных
+type ItemPrototypeGetParams struct {
+	Output       []string `json:"output"`
+	DiscoveryIDs []string `json:"discoveryids"`
+}
+
+// ItemPrototype представляет прототип элемента данных в Zabbix
+type ItemPrototype struct {
+	ItemID string `json:"itemid"`
+	Name   string `json:"name"`
+	Key    string `json:"key_"`
+}
+
+// ItemPrototypeCreateParams параметры для создания прототипа элемента данных
+type ItemPrototypeCreateParams struct {
 	Name        string `json:"name"`
 	Key         string `json:"key_"`
 	HostID      string `json:"hostid"`
+	RuleID      string `json:"ruleid"`
 	Type        int    `json:"type"`       // 2 - Zabbix trapper
 	ValueType   int    `json:"value_type"` // 0 - float, 3 - unsigned int
-	DataType    int    `json:"data_type"`  // 0 - decimal
 	Description string `json:"description,omitempty"`
+	Units       string `json:"units,omitempty"`
 	Status      int    `json:"status"` // 0 - enabled
 }
 
+// TriggerGetParams параметры для получения триггеров
+type TriggerGetParams struct {
+	Output             []string `json:"output"`
+	HostIDs            []string `json:"hostids"`
+	SelectTags         []string `json:"selectTags"`
+	SelectDependencies []string `json:"selectDependencies"`
+	ExpandExpression   bool     `json:"expandExpression"`
+	Inherited          bool     `json:"inherited"`
+}
+
+// Trigger представляет триггер в Zabbix
+type Trigger struct {
+	TriggerID    string       `json:"triggerid"`
+	Description  string       `json:"description"`
+	Expression   string       `json:"expression"`
+	Priority     string       `json:"priority"`
+	Comments     string       `json:"comments"`
+	Tags         []TriggerTag `json:"tags"`
+	Dependencies []TriggerID  `json:"dependencies"`
+}
+
+// TriggerTag представляет тег триггера
+type TriggerTag struct {
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+// TriggerID ссылка на триггер
+type TriggerID struct {
+	TriggerID string `json:"triggerid"`
+}
+
+// TriggerParams параметры для создания или обновления триггера
+type TriggerParams struct {
+	TriggerID   string       `json:"triggerid,omitempty"`
+	Description string       `json:"description"`
+	Expression  string       `json:"expression"`
+	Priority    int          `json:"priority"`
+	Comments    string       `json:"comments"`
+	Tags        []TriggerTag `json:"tags"`
+}
+
+// TriggerDependenciesParams параметры для обновления зависимостей триггера
+type TriggerDependenciesParams struct {
+	TriggerID    string      `json:"triggerid"`
+	Dependencies []TriggerID `json:"dependencies"`
+}
+
 // HistoryData представляет исторические данные для отправки
 type HistoryData struct {
 	ItemID string      `json:"itemid"`
@@ -96,35 +284,525 @@ type ZabbixMetricItem struct {
 	Name        string
 	ValueType   int // 0 - float, 3 - unsigned int
 	Description string
+	Units       string
+}
+
+// Важность триггера
+const (
+	SeverityNotClassified = 0
+	SeverityInformation   = 1
+	SeverityWarning       = 2
+	SeverityAverage       = 3
+	SeverityHigh          = 4
+	SeverityDisaster      = 5
+)
+
+// ZabbixTrigger описывает триггер, создаваемый вместе с элементами данных.
+// В выражении {HOST} заменяется на имя хоста, {THRESHOLD} - на порог.
+type ZabbixTrigger struct {
+	Name         string       `json:"name"`
+	Expression   string       `json:"expression"`
+	Threshold    string       `json:"threshold,omitempty"`
+	Severity     int          `json:"severity"`
+	Description  string       `json:"description,omitempty"`
+	Tags         []TriggerTag `json:"tags,omitempty"`
+	Dependencies []string     `json:"dependencies,omitempty"` // имена триггеров
+	Builtin      bool         `json:"-"`                      // встроенное выражение, создается только для включенных ключей
+}
+
+// ZabbixDiscoveryRule представляет правило низкоуровневого обнаружения с прототипами элементов
+type ZabbixDiscoveryRule struct {
+	Key         string
+	Name        string
+	Description string
+	Prototypes  []ZabbixMetricItem
+}
+
+// GetZabbixDiscoveryRules возвращает список правил обнаружения, которые должны быть созданы в Zabbix
+func GetZabbixDiscoveryRules() []ZabbixDiscoveryRule {
+	return []ZabbixDiscoveryRule{
+		// Ядра процессора
+		{
+			Key:         "system.cpu.discovery",
+			Name:        "CPU discovery",
+			Description: "Discovery of logical CPUs",
+			Prototypes: []ZabbixMetricItem{
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},user]",
+					Name:        "CPU #{#CPU.NUMBER}: User time",
+					ValueType:   0, // float
+					Description: "Percentage of user time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},system]",
+					Name:        "CPU #{#CPU.NUMBER}: System time",
+					ValueType:   0, // float
+					Description: "Percentage of system time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},iowait]",
+					Name:        "CPU #{#CPU.NUMBER}: Iowait time",
+					ValueType:   0, // float
+					Description: "Percentage of iowait time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},steal]",
+					Name:        "CPU #{#CPU.NUMBER}: Steal time",
+					ValueType:   0, // float
+					Description: "Percentage of steal time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},interrupt]",
+					Name:        "CPU #{#CPU.NUMBER}: Interrupt time",
+					ValueType:   0, // float
+					Description: "Percentage of interrupt time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},softirq]",
+					Name:        "CPU #{#CPU.NUMBER}: Softirq time",
+					ValueType:   0, // float
+					Description: "Percentage of softirq time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},nice]",
+					Name:        "CPU #{#CPU.NUMBER}: Nice time",
+					ValueType:   0, // float
+					Description: "Percentage of nice time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},guest]",
+					Name:        "CPU #{#CPU.NUMBER}: Guest time",
+					ValueType:   0, // float
+					Description: "Percentage of guest time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+				{
+					Key:         "system.cpu.util[{#CPU.NUMBER},idle]",
+					Name:        "CPU #{#CPU.NUMBER}: Idle time",
+					ValueType:   0, // float
+					Description: "Percentage of idle time on CPU #{#CPU.NUMBER}",
+					Units:       "%",
+				},
+			},
+		},
+		// Файловые системы
+		{
+			Key:         "vfs.fs.discovery",
+			Name:        "Mounted filesystem discovery",
+			Description: "Discovery of mounted filesystems",
+			Prototypes: []ZabbixMetricItem{
+				{
+					Key:         "vfs.fs.size[{#FSNAME},total]",
+					Name:        "{#FSNAME}: Total space",
+					ValueType:   3, // unsigned int
+					Description: "Total filesystem space in bytes",
+					Units:       "B",
+				},
+				{
+					Key:         "vfs.fs.size[{#FSNAME},used]",
+					Name:        "{#FSNAME}: Used space",
+					ValueType:   3, // unsigned int
+					Description: "Used filesystem space in bytes",
+					Units:       "B",
+				},
+				{
+					Key:         "vfs.fs.size[{#FSNAME},free]",
+					Name:        "{#FSNAME}: Free space",
+					ValueType:   3, // unsigned int
+					Description: "Free filesystem space in bytes",
+					Units:       "B",
+				},
+				{
+					Key:         "vfs.fs.pused[{#FSNAME}]",
+					Name:        "{#FSNAME}: Space utilization",
+					ValueType:   0, // float
+					Description: "Filesystem usage percentage",
+					Units:       "%",
+				},
+				{
+					Key:         "vfs.fs.inode[{#FSNAME},total]",
+					Name:        "{#FSNAME}: Total inodes",
+					ValueType:   3, // unsigned int
+					Description: "Total number of inodes",
+				},
+				{
+					Key:         "vfs.fs.inode[{#FSNAME},used]",
+					Name:        "{#FSNAME}: Used inodes",
+					ValueType:   3, // unsigned int
+					Description: "Number of used inodes",
+				},
+				{
+					Key:         "vfs.fs.inode[{#FSNAME},free]",
+					Name:        "{#FSNAME}: Free inodes",
+					ValueType:   3, // unsigned int
+					Description: "Number of free inodes",
+				},
+				{
+					Key:         "vfs.fs.inode[{#FSNAME},pused]",
+					Name:        "{#FSNAME}: Inode utilization",
+					ValueType:   0, // float
+					Description: "Inode usage percentage",
+					Units:       "%",
+				},
+			},
+		},
+		// Блочные устройства
+		{
+			Key:         "vfs.dev.discovery",
+			Name:        "Block device discovery",
+			Description: "Discovery of block devices",
+			Prototypes: []ZabbixMetricItem{
+				{
+					Key:         "vfs.dev.read[{#DEVNAME},operations]",
+					Name:        "{#DEVNAME}: Disk read operations",
+					ValueType:   3, // unsigned int
+					Description: "Number of completed read operations",
+				},
+				{
+					Key:         "vfs.dev.read[{#DEVNAME},ops]",
+					Name:        "{#DEVNAME}: Disk read rate",
+					ValueType:   0, // float
+					Description: "Read operations per second",
+				},
+				{
+					Key:         "vfs.dev.read[{#DEVNAME},bytes]",
+					Name:        "{#DEVNAME}: Disk read bytes",
+					ValueType:   3, // unsigned int
+					Description: "Number of bytes read",
+					Units:       "B",
+				},
+				{
+					Key:         "vfs.dev.read[{#DEVNAME},bps]",
+					Name:        "{#DEVNAME}: Disk read throughput",
+					ValueType:   0, // float
+					Description: "Bytes read per second",
+					Units:       "Bps",
+				},
+				{
+					Key:         "vfs.dev.read[{#DEVNAME},merged]",
+					Name:        "{#DEVNAME}: Disk read merged operations",
+					ValueType:   3, // unsigned int
+					Description: "Number of merged read operations",
+				},
+				{
+					Key:         "vfs.dev.read.rate[{#DEVNAME},merged]",
+					Name:        "{#DEVNAME}: Disk read merged rate",
+					ValueType:   0, // float
+					Description: "Merged read operations per second",
+				},
+				{
+					Key:         "vfs.dev.read[{#DEVNAME},time]",
+					Name:        "{#DEVNAME}: Disk read time",
+					ValueType:   3, // unsigned int
+					Description: "Total time spent on read operations in milliseconds",
+					Units:       "ms",
+				},
+				{
+					Key:         "vfs.dev.read.await[{#DEVNAME}]",
+					Name:        "{#DEVNAME}: Disk read request avg waiting time",
+					ValueType:   0, // float
+					Description: "Average read request time in milliseconds",
+					Units:       "ms",
+				},
+				{
+					Key:         "vfs.dev.write[{#DEVNAME},operations]",
+					Name:        "{#DEVNAME}: Disk write operations",
+					ValueType:   3, // unsigned int
+					Description: "Number of completed write operations",
+				},
+				{
+					Key:         "vfs.dev.write[{#DEVNAME},ops]",
+					Name:        "{#DEVNAME}: Disk write rate",
+					ValueType:   0, // float
+					Description: "Write operations per second",
+				},
+				{
+					Key:         "vfs.dev.write[{#DEVNAME},bytes]",
+					Name:        "{#DEVNAME}: Disk write bytes",
+					ValueType:   3, // unsigned int
+					Description: "Number of bytes written",
+					Units:       "B",
+				},
+				{
+					Key:         "vfs.dev.write[{#DEVNAME},bps]",
+					Name:        "{#DEVNAME}: Disk write throughput",
+					ValueType:   0, // float
+					Description: "Bytes written per second",
+					Units:       "Bps",
+				},
+				{
+					Key:         "vfs.dev.write[{#DEVNAME},merged]",
+					Name:        "{#DEVNAME}: Disk write merged operations",
+					ValueType:   3, // unsigned int
+					Description: "Number of merged write operations",
+				},
+				{
+					Key:         "vfs.dev.write.rate[{#DEVNAME},merged]",
+					Name:        "{#DEVNAME}: Disk write merged rate",
+					ValueType:   0, // float
+					Description: "Merged write operations per second",
+				},
+				{
+					Key:         "vfs.dev.write[{#DEVNAME},time]",
+					Name:        "{#DEVNAME}: Disk write time",
+					ValueType:   3, // unsigned int
+					Description: "Total time spent on write operations in milliseconds",
+					Units:       "ms",
+				},
+				{
+					Key:         "vfs.dev.write.await[{#DEVNAME}]",
+					Name:        "{#DEVNAME}: Disk write request avg waiting time",
+					ValueType:   0, // float
+					Description: "Average write request time in milliseconds",
+					Units:       "ms",
+				},
+				{
+					Key:         "vfs.dev.in_progress[{#DEVNAME}]",
+					Name:        "{#DEVNAME}: Disk operations in progress",
+					ValueType:   3, // unsigned int
+					Description: "Number of I/O operations currently in progress",
+				},
+				{
+					Key:         "vfs.dev.queue_size[{#DEVNAME}]",
+					Name:        "{#DEVNAME}: Disk average queue size",
+					ValueType:   0, // float
+					Description: "Average number of queued I/O requests",
+				},
+				{
+					Key:         "vfs.dev.util[{#DEVNAME}]",
+					Name:        "{#DEVNAME}: Disk utilization",
+					ValueType:   0, // float
+					Description: "Percentage of time the device was busy with I/O",
+					Units:       "%",
+				},
+			},
+		},
+		// Сетевые интерфейсы
+		{
+			Key:         "net.if.discovery",
+			Name:        "Network interface discovery",
+			Description: "Discovery of network interfaces",
+			Prototypes: []ZabbixMetricItem{
+				{
+					Key:         "net.if.in[{#IFNAME}]",
+					Name:        "Interface {#IFNAME}: Incoming traffic",
+					ValueType:   3, // unsigned int
+					Description: "Bytes received on interface {#IFNAME}",
+					Units:       "B",
+				},
+				{
+					Key:         "net.if.out[{#IFNAME}]",
+					Name:        "Interface {#IFNAME}: Outgoing traffic",
+					ValueType:   3, // unsigned int
+					Description: "Bytes sent on interface {#IFNAME}",
+					Units:       "B",
+				},
+				{
+					Key:         "net.if.in[{#IFNAME},packets]",
+					Name:        "Interface {#IFNAME}: Incoming packets",
+					ValueType:   3, // unsigned int
+					Description: "Packets received on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.out[{#IFNAME},packets]",
+					Name:        "Interface {#IFNAME}: Outgoing packets",
+					ValueType:   3, // unsigned int
+					Description: "Packets sent on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.in[{#IFNAME},errors]",
+					Name:        "Interface {#IFNAME}: Incoming errors",
+					ValueType:   3, // unsigned int
+					Description: "Input errors on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.out[{#IFNAME},errors]",
+					Name:        "Interface {#IFNAME}: Outgoing errors",
+					ValueType:   3, // unsigned int
+					Description: "Output errors on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.in[{#IFNAME},dropped]",
+					Name:        "Interface {#IFNAME}: Incoming dropped packets",
+					ValueType:   3, // unsigned int
+					Description: "Dropped packets received on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.out[{#IFNAME},dropped]",
+					Name:        "Interface {#IFNAME}: Outgoing dropped packets",
+					ValueType:   3, // unsigned int
+					Description: "Dropped packets sent on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.in.rate[{#IFNAME}]",
+					Name:        "Interface {#IFNAME}: Incoming traffic per second",
+					ValueType:   0, // float
+					Description: "Bytes received per second on interface {#IFNAME}",
+					Units:       "Bps",
+				},
+				{
+					Key:         "net.if.out.rate[{#IFNAME}]",
+					Name:        "Interface {#IFNAME}: Outgoing traffic per second",
+					ValueType:   0, // float
+					Description: "Bytes sent per second on interface {#IFNAME}",
+					Units:       "Bps",
+				},
+				{
+					Key:         "net.if.in.rate[{#IFNAME},packets]",
+					Name:        "Interface {#IFNAME}: Incoming packets per second",
+					ValueType:   0, // float
+					Description: "Packets received per second on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.out.rate[{#IFNAME},packets]",
+					Name:        "Interface {#IFNAME}: Outgoing packets per second",
+					ValueType:   0, // float
+					Description: "Packets sent per second on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.in.rate[{#IFNAME},errors]",
+					Name:        "Interface {#IFNAME}: Incoming errors per second",
+					ValueType:   0, // float
+					Description: "Input errors per second on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.out.rate[{#IFNAME},errors]",
+					Name:        "Interface {#IFNAME}: Outgoing errors per second",
+					ValueType:   0, // float
+					Description: "Output errors per second on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.in.rate[{#IFNAME},dropped]",
+					Name:        "Interface {#IFNAME}: Incoming dropped packets per second",
+					ValueType:   0, // float
+					Description: "Dropped packets received per second on interface {#IFNAME}",
+				},
+				{
+					Key:         "net.if.out.rate[{#IFNAME},dropped]",
+					Name:        "Interface {#IFNAME}: Outgoing dropped packets per second",
+					ValueType:   0, // float
+					Description: "Dropped packets sent per second on interface {#IFNAME}",
+				},
+			},
+		},
+	}
 }
 
 // GetZabbixItems возвращает список всех метрик, которые должны быть созданы в Zabbix
 func GetZabbixItems() []ZabbixMetricItem {
 	return []ZabbixMetricItem{
 		// CPU метрики
+		{
+			Key:         "system.cpu.util[,user]",
+			Name:        "CPU user time",
+			ValueType:   0, // float
+			Description: "Time the CPU has spent running user space processes, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.util[,system]",
+			Name:        "CPU system time",
+			ValueType:   0, // float
+			Description: "Time the CPU has spent running the kernel and its processes, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.util[,iowait]",
+			Name:        "CPU iowait time",
+			ValueType:   0, // float
+			Description: "Time the CPU has been waiting for I/O to complete, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.util[,steal]",
+			Name:        "CPU steal time",
+			ValueType:   0, // float
+			Description: "Time stolen by the hypervisor for other virtual machines, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.util[,interrupt]",
+			Name:        "CPU interrupt time",
+			ValueType:   0, // float
+			Description: "Time the CPU has spent servicing hardware interrupts, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.util[,softirq]",
+			Name:        "CPU softirq time",
+			ValueType:   0, // float
+			Description: "Time the CPU has spent servicing software interrupts, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.util[,nice]",
+			Name:        "CPU nice time",
+			ValueType:   0, // float
+			Description: "Time the CPU has spent running niced user processes, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.util[,guest]",
+			Name:        "CPU guest time",
+			ValueType:   0, // float
+			Description: "Time the CPU has spent running virtual processors of guests, percentage",
+			Units:       "%",
+		},
 		{
 			Key:         "system.cpu.util[,idle]",
-			Name:        "CPU utilization",
+			Name:        "CPU idle time",
 			ValueType:   0, // float
-			Description: "CPU usage percentage",
+			Description: "Time the CPU has spent doing nothing, percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.cpu.load[all,avg1]",
+			Name:        "Processor load (1 min average)",
+			ValueType:   0, // float
+			Description: "1 minute load average",
+		},
+		{
+			Key:         "system.cpu.load[all,avg5]",
+			Name:        "Processor load (5 min average)",
+			ValueType:   0, // float
+			Description: "5 minute load average",
+		},
+		{
+			Key:         "system.cpu.load[all,avg15]",
+			Name:        "Processor load (15 min average)",
+			ValueType:   0, // float
+			Description: "15 minute load average",
 		},
 		{
 			Key:         "system.cpu.load[percpu,avg1]",
 			Name:        "Processor load (1 min average per core)",
 			ValueType:   0, // float
-			Description: "1 minute load average",
+			Description: "1 minute load average divided by the number of logical CPUs",
 		},
 		{
 			Key:         "system.cpu.load[percpu,avg5]",
 			Name:        "Processor load (5 min average per core)",
 			ValueType:   0, // float
-			Description: "5 minute load average",
+			Description: "5 minute load average divided by the number of logical CPUs",
 		},
 		{
 			Key:         "system.cpu.load[percpu,avg15]",
 			Name:        "Processor load (15 min average per core)",
 			ValueType:   0, // float
-			Description: "15 minute load average",
+			Description: "15 minute load average divided by the number of logical CPUs",
+		},
+		{
+			Key:         "system.cpu.num",
+			Name:        "Number of CPUs",
+			ValueType:   3, // unsigned int
+			Description: "Number of logical CPUs",
 		},
 
 		// Memory метрики
@@ -133,50 +811,159 @@ func GetZabbixItems() []ZabbixMetricItem {
 			Name:        "Total memory",
 			ValueType:   3, // unsigned int
 			Description: "Total memory in bytes",
+			Units:       "B",
 		},
 		{
 			Key:         "vm.memory.size[used]",
 			Name:        "Used memory",
 			ValueType:   3, // unsigned int
 			Description: "Used memory in bytes",
+			Units:       "B",
 		},
 		{
 			Key:         "vm.memory.size[available]",
 			Name:        "Available memory",
 			ValueType:   3, // unsigned int
 			Description: "Available memory in bytes",
+			Units:       "B",
 		},
 		{
 			Key:         "vm.memory.util",
 			Name:        "Memory utilization",
 			ValueType:   0, // float
 			Description: "Memory usage percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "vm.memory.size[free]",
+			Name:        "Free memory",
+			ValueType:   3, // unsigned int
+			Description: "Free memory in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[buffers]",
+			Name:        "Memory buffers",
+			ValueType:   3, // unsigned int
+			Description: "Memory used by kernel buffers in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[cached]",
+			Name:        "Memory cached",
+			ValueType:   3, // unsigned int
+			Description: "Memory used by the page cache in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[shared]",
+			Name:        "Memory shared",
+			ValueType:   3, // unsigned int
+			Description: "Memory used by shared memory and tmpfs in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[slab]",
+			Name:        "Memory slab",
+			ValueType:   3, // unsigned int
+			Description: "Memory used by kernel slab allocator in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[dirty]",
+			Name:        "Memory dirty",
+			ValueType:   3, // unsigned int
+			Description: "Memory waiting to be written back to disk in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[writeback]",
+			Name:        "Memory writeback",
+			ValueType:   3, // unsigned int
+			Description: "Memory actively being written back to disk in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[committed_as]",
+			Name:        "Memory committed",
+			ValueType:   3, // unsigned int
+			Description: "Memory committed to allocations (Committed_AS) in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[hugepages_total]",
+			Name:        "Huge pages total",
+			ValueType:   3, // unsigned int
+			Description: "Total size of the huge page pool in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "vm.memory.size[hugepages_free]",
+			Name:        "Huge pages free",
+			ValueType:   3, // unsigned int
+			Description: "Free size of the huge page pool in bytes",
+			Units:       "B",
 		},
 
-		// Disk метрики
+		// Swap метрики
 		{
-			Key:         "vfs.fs.size[/,total]",
-			Name:        "Free disk space on / (total)",
+			Key:         "system.swap.size[,total]",
+			Name:        "Total swap space",
 			ValueType:   3, // unsigned int
-			Description: "Total disk space in bytes",
+			Description: "Total swap space in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "system.swap.size[,used]",
+			Name:        "Used swap space",
+			ValueType:   3, // unsigned int
+			Description: "Used swap space in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "system.swap.size[,free]",
+			Name:        "Free swap space",
+			ValueType:   3, // unsigned int
+			Description: "Free swap space in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "system.swap.size[,pused]",
+			Name:        "Swap space utilization",
+			ValueType:   0, // float
+			Description: "Swap usage percentage",
+			Units:       "%",
+		},
+		{
+			Key:         "system.swap.size[,pfree]",
+			Name:        "Free swap space in %",
+			ValueType:   0, // float
+			Description: "Free swap space percentage",
+			Units:       "%",
 		},
 		{
-			Key:         "vfs.fs.size[/,used]",
-			Name:        "Used disk space on /",
+			Key:         "system.swap.in[,pages]",
+			Name:        "Swap in",
 			ValueType:   3, // unsigned int
-			Description: "Used disk space in bytes",
+			Description: "Number of pages swapped in",
 		},
 		{
-			Key:         "vfs.fs.size[/,free]",
-			Name:        "Free disk space on /",
+			Key:         "system.swap.out[,pages]",
+			Name:        "Swap out",
 			ValueType:   3, // unsigned int
-			Description: "Free disk space in bytes",
+			Description: "Number of pages swapped out",
+		},
+		{
+			Key:         "system.swap.in.rate[,pages]",
+			Name:        "Swap in per second",
+			ValueType:   0, // float
+			Description: "Pages swapped in per second",
 		},
 		{
-			Key:         "vfs.fs.pused[/]",
-			Name:        "Free disk space on / (percentage used)",
+			Key:         "system.swap.out.rate[,pages]",
+			Name:        "Swap out per second",
 			ValueType:   0, // float
-			Description: "Disk usage percentage",
+			Description: "Pages swapped out per second",
 		},
 
 		// Network метрики
@@ -185,12 +972,14 @@ func GetZabbixItems() []ZabbixMetricItem {
 			Name:        "Incoming network traffic on all interfaces",
 			ValueType:   3, // unsigned int
 			Description: "Bytes received on all network interfaces",
+			Units:       "B",
 		},
 		{
 			Key:         "net.if.out[all]",
 			Name:        "Outgoing network traffic on all interfaces",
 			ValueType:   3, // unsigned int
 			Description: "Bytes sent on all network interfaces",
+			Units:       "B",
 		},
 		{
 			Key:         "net.if.in[all,packets]",
@@ -216,5 +1005,136 @@ func GetZabbixItems() []ZabbixMetricItem {
 			ValueType:   3, // unsigned int
 			Description: "Output errors on all network interfaces",
 		},
+		{
+			Key:         "net.if.in.rate[all]",
+			Name:        "Incoming traffic on all interfaces per second",
+			ValueType:   0, // float
+			Description: "Bytes received per second on all network interfaces",
+			Units:       "Bps",
+		},
+		{
+			Key:         "net.if.out.rate[all]",
+			Name:        "Outgoing traffic on all interfaces per second",
+			ValueType:   0, // float
+			Description: "Bytes sent per second on all network interfaces",
+			Units:       "Bps",
+		},
+		{
+			Key:         "net.if.in.rate[all,packets]",
+			Name:        "Incoming packets on all interfaces per second",
+			ValueType:   0, // float
+			Description: "Packets received per second on all network interfaces",
+		},
+		{
+			Key:         "net.if.out.rate[all,packets]",
+			Name:        "Outgoing packets on all interfaces per second",
+			ValueType:   0, // float
+			Description: "Packets sent per second on all network interfaces",
+		},
+		{
+			Key:         "net.if.in.rate[all,errors]",
+			Name:        "Incoming errors on all interfaces per second",
+			ValueType:   0, // float
+			Description: "Input errors per second on all network interfaces",
+		},
+		{
+			Key:         "net.if.out.rate[all,errors]",
+			Name:        "Outgoing errors on all interfaces per second",
+			ValueType:   0, // float
+			Description: "Output errors per second on all network interfaces",
+		},
+
+		// Самомониторинг очереди неотправленных значений
+		{
+			Key:         "zabbix_mon.queue.batches",
+			Name:        "zabbix_mon queue batches",
+			ValueType:   3, // unsigned int
+			Description: "Number of unsent batches waiting in the disk queue",
+		},
+		{
+			Key:         "zabbix_mon.queue.values",
+			Name:        "zabbix_mon queue values",
+			ValueType:   3, // unsigned int
+			Description: "Number of unsent values waiting in the disk queue",
+		},
+		{
+			Key:         "zabbix_mon.queue.size",
+			Name:        "zabbix_mon queue size",
+			ValueType:   3, // unsigned int
+			Description: "Size of the disk queue in bytes",
+			Units:       "B",
+		},
+		{
+			Key:         "zabbix_mon.queue.age",
+			Name:        "zabbix_mon queue age",
+			ValueType:   0, // float
+			Description: "Age of the oldest batch in the disk queue, seconds",
+			Units:       "s",
+		},
+	}
+}
+
+// GetZabbixTriggers возвращает триггеры для элементов данных из GetZabbixItems.
+// Выражения используют синтаксис Zabbix 5.4 и новее.
+func GetZabbixTriggers() []ZabbixTrigger {
+	return []ZabbixTrigger{
+		{
+			Name:        "No data from zabbix_mon",
+			Expression:  "nodata(/{HOST}/system.cpu.num,{THRESHOLD})=1",
+			Threshold:   "5m",
+			Severity:    SeverityAverage,
+			Description: "zabbix_mon has not sent data for the threshold period",
+			Tags:        []TriggerTag{{Tag: "scope", Value: "availability"}},
+		},
+		{
+			Name:        "High CPU utilization",
+			Expression:  "min(/{HOST}/system.cpu.util[,idle],5m)<{THRESHOLD}",
+			Threshold:   "10",
+			Severity:    SeverityWarning,
+			Description: "CPU idle time is below the threshold percentage for 5 minutes",
+			Tags:        []TriggerTag{{Tag: "scope", Value: "performance"}, {Tag: "component", Value: "cpu"}},
+		},
+		{
+			Name:        "Load average is too high",
+			Expression:  "min(/{HOST}/system.cpu.load[all,avg1],5m)/last(/{HOST}/system.cpu.num)>{THRESHOLD}",
+			Threshold:   "1.5",
+			Severity:    SeverityAverage,
+			Description: "Load average per CPU is above the threshold for 5 minutes",
+			Tags:        []TriggerTag{{Tag: "scope", Value: "performance"}, {Tag: "component", Value: "cpu"}},
+		},
+		{
+			Name:        "Lack of available memory",
+			Expression:  "max(/{HOST}/vm.memory.size[available],5m)<{THRESHOLD}",
+			Threshold:   "20M",
+			Severity:    SeverityAverage,
+			Description: "Available memory is below the threshold for 5 minutes",
+			Tags:        []TriggerTag{{Tag: "scope", Value: "capacity"}, {Tag: "component", Value: "memory"}},
+		},
+		{
+			Name:         "High memory utilization",
+			Expression:   "min(/{HOST}/vm.memory.util,5m)>{THRESHOLD}",
+			Threshold:    "90",
+			Severity:     SeverityWarning,
+			Description:  "Memory utilization is above the threshold percentage for 5 minutes",
+			Tags:         []TriggerTag{{Tag: "scope", Value: "capacity"}, {Tag: "component", Value: "memory"}},
+			Dependencies: []string{"Lack of available memory"},
+		},
+		{
+			Name:         "High swap space usage",
+			Expression:   "max(/{HOST}/system.swap.size[,pfree],5m)<{THRESHOLD} and last(/{HOST}/system.swap.size[,total])>0",
+			Threshold:    "50",
+			Severity:     SeverityWarning,
+			Description:  "Free swap space is below the threshold percentage for 5 minutes",
+			Tags:         []TriggerTag{{Tag: "scope", Value: "capacity"}, {Tag: "component", Value: "memory"}},
+			Dependencies: []string{"Lack of available memory", "High memory utilization"},
+		},
+		{
+			Name:        "Metrics are queued for later delivery",
+			Expression:  "min(/{HOST}/zabbix_mon.queue.age,5m)>{THRESHOLD}",
+			Threshold:   "600",
+			Severity:    SeverityWarning,
+			Description: "The oldest undelivered batch in the disk queue is older than the threshold in seconds",
+			Tags:        []TriggerTag{{Tag: "scope", Value: "availability"}, {Tag: "component", Value: "zabbix_mon"}},
+		},
 	}
 }